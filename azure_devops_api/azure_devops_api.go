@@ -9,7 +9,11 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -23,13 +27,81 @@ import (
 )
 
 type Configuration struct {
-	PersonalAccessToken string `json:"PersonalAccessToken"`
-	OrganizationName    string `json:"OrganizationName"`
-	TeamName            string `json:"TeamName"`
-	ProjectName         string `json:"ProjectName"`
-	SprintName          string `json:"SprintName"`
-	AreaPath            string `json:"AreaPath"`
-	SystemAreaID        string `json:"SystemAreaID"`
+	PersonalAccessToken string   `json:"PersonalAccessToken"`
+	OrganizationName    string   `json:"OrganizationName"`
+	TeamName            string   `json:"TeamName"`
+	ProjectName         string   `json:"ProjectName"`
+	SprintName          string   `json:"SprintName"`
+	AreaPath            string   `json:"AreaPath"`
+	SystemAreaID        string   `json:"SystemAreaID"`
+	BaseURL             string   `json:"BaseURL"`
+	WiqlPageSize        int      `json:"WiqlPageSize"`
+	CacheFilePath       string   `json:"CacheFilePath"`
+	Fields              []string `json:"Fields"`
+	ExpandRelations     bool     `json:"ExpandRelations"`
+	// WIQL selecting the downloaded work items, see wiqlCondition.
+	DownloadScope string   `json:"DownloadScope"`
+	WiqlCondition string   `json:"WiqlCondition"`
+	WiqlFilters   []string `json:"WiqlFilters"`
+	Assignee      string   `json:"Assignee"`
+	// Client settings, the defaults when zero. MaxRetries below zero disables retries.
+	MaxConcurrency        int `json:"MaxConcurrency"`
+	MaxRetries            int `json:"MaxRetries"`
+	RequestTimeoutSeconds int `json:"RequestTimeoutSeconds"`
+}
+
+// Request dict keys with the prefix set the work item field named by the rest of the key.
+const FieldKeyPrefix = "Field:"
+
+// ResponseError is a failed REST call with the error returned by Azure DevOps.
+// RuleErrors are the work item rules a patch broke, like a forbidden state
+// transition or a missing required field.
+type ResponseError struct {
+	StatusCode int
+	Status     string
+	Message    string
+	TypeKey    string
+	RuleErrors []RuleValidationError
+}
+
+type RuleValidationError struct {
+	FieldReferenceName string `json:"fieldReferenceName"`
+	ErrorMessage       string `json:"errorMessage"`
+}
+
+func (e *ResponseError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("HTTP status error: %d %s", e.StatusCode, e.Status)
+	}
+	return fmt.Sprintf("HTTP status error: %d %s: %s", e.StatusCode, e.Status, e.Message)
+}
+
+// Rule errors are reported as TF401320, with the broken rules when the service lists them.
+func (e *ResponseError) IsRuleError() bool {
+	return e.TypeKey == "RuleValidationException" || len(e.RuleErrors) > 0 || strings.Contains(e.Message, "TF401320")
+}
+
+// Read the error of a failed response, the body is not always JSON.
+func newResponseError(resp *http.Response) error {
+	responseError := &ResponseError{StatusCode: resp.StatusCode, Status: resp.Status}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return responseError
+	}
+	var errorBody struct {
+		Message          string `json:"message"`
+		TypeKey          string `json:"typeKey"`
+		CustomProperties struct {
+			RuleValidationErrors []RuleValidationError `json:"RuleValidationErrors"`
+		} `json:"customProperties"`
+	}
+	if json.Unmarshal(body, &errorBody) != nil {
+		return responseError
+	}
+	responseError.Message = errorBody.Message
+	responseError.TypeKey = errorBody.TypeKey
+	responseError.RuleErrors = errorBody.CustomProperties.RuleValidationErrors
+	return responseError
 }
 
 type WorkItem struct {
@@ -114,7 +186,11 @@ func GetAllWits(config Configuration) error {
 	ctx := context.Background()
 
 	// Fetch work item IDs in batches using WIQL
-	ids, err := getWorkItemIDs(config, ctx)
+	condition, err := wiqlCondition(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ids, err := getWorkItemIDs(config, ctx, condition, time.Time{})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -217,37 +293,58 @@ func GetTeamUuid(config Configuration) (id uuid.UUID, err error) {
 	return id, err
 }
 
+// The iteration of the default team named SprintName.
 func GetIteration(config Configuration) (iteration work.TeamSettingsIteration, err error) {
-	WorkClient, ctx, err := GetWorkClientAndCtx(config)
-
+	req, err := createRequest(config, context.Background(), "work/teamsettings/iterations?api-version=7.0", http.MethodGet, nil, "application/json")
 	if err != nil {
 		return iteration, err
 	}
+	resp, err := getClient(config).Do(req)
+	if err != nil {
+		return iteration, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return iteration, newResponseError(resp)
+	}
 
-	TeamSettingsIterations, err := WorkClient.GetTeamIterations(ctx, work.GetTeamIterationsArgs{Project: &(config.ProjectName)})
-
-	if TeamSettingsIterations == nil {
+	var TeamSettingsIterations struct {
+		Value []work.TeamSettingsIteration `json:"value"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&TeamSettingsIterations)
+	if err != nil {
 		return iteration, err
 	}
-	for _, TeamSettingsIteration := range *TeamSettingsIterations {
-		if *TeamSettingsIteration.Name == config.SprintName {
+	for _, TeamSettingsIteration := range TeamSettingsIterations.Value {
+		if TeamSettingsIteration.Name != nil && *TeamSettingsIteration.Name == config.SprintName {
 			return TeamSettingsIteration, nil
 		}
 	}
 	return iteration, fmt.Errorf("was not able to find Iteration by name: %s", config.SprintName)
 }
 
-func CallGetWorkItems(config Configuration, ctx context.Context, WorkItemTrackingClient workitemtracking.Client, WitIds []int, ch chan *[]workitemtracking.WorkItem) (err error) {
-	Fields := []string{"System.State", "System.Id", "System.CreatedBy", "System.CreatedDate"}
-	args := workitemtracking.GetWorkItemsArgs{Project: &config.ProjectName, Ids: &WitIds, Fields: &Fields}
-	IterationWorkItems, err := WorkItemTrackingClient.GetWorkItems(ctx, args)
+// Work item type names by category reference name, like "Microsoft.TaskCategory".
+func GetWorkItemTypeCategories(config Configuration) (map[string][]string, error) {
+	WorkItemTrackingClient, ctx, err := GetWorkItemTrackingClientAndCtx(config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	ch <- IterationWorkItems
-	close(ch)
-	return nil
+	categories, err := WorkItemTrackingClient.GetWorkItemTypeCategories(ctx, workitemtracking.GetWorkItemTypeCategoriesArgs{Project: &(config.ProjectName)})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string][]string)
+	for _, category := range *categories {
+		if category.ReferenceName == nil || category.WorkItemTypes == nil {
+			continue
+		}
+		for _, workItemType := range *category.WorkItemTypes {
+			ret[*category.ReferenceName] = append(ret[*category.ReferenceName], *workItemType.Name)
+		}
+	}
+	return ret, nil
 }
 
 func GetAllFields() error {
@@ -295,23 +392,60 @@ func LoadConfig(configFilePath string) (config Configuration, err error) {
 	return config, nil
 }
 
-func getWorkItemIDs(config Configuration, ctx context.Context) ([]int, error) {
-	client := http.Client{Timeout: 10 * time.Second}
-	requestUrl := "https://dev.azure.com/" + config.OrganizationName + "/" + config.ProjectName + "/_apis/wit/wiql?api-version=7.0"
-	wiqlData := fmt.Sprintf(`{"query": "SELECT [System.Id] FROM WorkItems Where [System.TeamProject] = '%s' AND [System.AreaId] = %s"}`, config.ProjectName, config.SystemAreaID)
-	AuthHeaderValue := "Basic " + basicAuth(config.PersonalAccessToken)
+// WIQL returns at most this many work items per query.
+const maxWiqlPageSize = 20000
 
-	jsonBody := []byte(wiqlData)
-	bodyReader := bytes.NewReader(jsonBody)
+// IDs of the work items matching the wiqlCondition, only the ones changed after changedSince when it is set.
+// The query is paged by ID ranges, WiqlPageSize IDs at a time, so areas over the WIQL limit
+// are read in full.
+func getWorkItemIDs(config Configuration, ctx context.Context, condition string, changedSince time.Time) ([]int, error) {
+	pageSize := maxWiqlPageSize
+	if config.WiqlPageSize > 0 {
+		pageSize = min(config.WiqlPageSize, maxWiqlPageSize)
+	}
+	condition = "(" + condition + ")"
+	if !changedSince.IsZero() {
+		condition += fmt.Sprintf(" AND [System.ChangedDate] > '%s'", changedSince.UTC().Format(time.RFC3339))
+	}
+
+	allIDs := []int{}
+	for lastId := 0; ; {
+		query := fmt.Sprintf("SELECT [System.Id] FROM WorkItems WHERE %s AND [System.Id] > %d ORDER BY [System.Id]", condition, lastId)
+		ids, err := queryWorkItemIDs(config, ctx, query, pageSize, !changedSince.IsZero())
+		if err != nil {
+			return nil, err
+		}
+		allIDs = append(allIDs, ids...)
+		if len(ids) < pageSize {
+			break
+		}
+		lastId = ids[len(ids)-1]
+		log.Printf("queried %d work item IDs, continuing after ID %d\n", len(allIDs), lastId)
+	}
+	if len(allIDs) == 0 && changedSince.IsZero() {
+		return nil, fmt.Errorf("was not able to fetch Work Item Ids, check the query conditions: %s", condition)
+	}
+	log.Printf("queried %d work item IDs\n", len(allIDs))
+	return allIDs, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, requestUrl, bodyReader)
+// Run a flat WIQL query returning at most top IDs.
+func queryWorkItemIDs(config Configuration, ctx context.Context, query string, top int, timePrecision bool) ([]int, error) {
+	client := getClient(config)
+	requestPath := fmt.Sprintf("wit/wiql?$top=%d&api-version=7.0", top)
+	if timePrecision {
+		// Without timePrecision WIQL compares dates by day.
+		requestPath = fmt.Sprintf("wit/wiql?$top=%d&timePrecision=true&api-version=7.0", top)
+	}
+	wiqlData, err := json.Marshal(map[string]string{"query": query})
 	if err != nil {
-		return []int{}, err
+		return nil, fmt.Errorf("error marshaling JSON: %v", err)
 	}
 
-	// Set the Authorization header
-	req.Header.Set("Authorization", AuthHeaderValue)
-	req.Header.Set("Content-Type", "application/json")
+	req, err := createRequest(config, ctx, requestPath, http.MethodPost, bytes.NewReader(wiqlData), "application/json")
+	if err != nil {
+		return nil, err
+	}
 
 	// Send the request
 
@@ -324,7 +458,7 @@ func getWorkItemIDs(config Configuration, ctx context.Context) ([]int, error) {
 
 	// Check the status code
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP status error: %d %s", resp.StatusCode, resp.Status)
+		return nil, newResponseError(resp)
 	}
 
 	// Decode the JSON response
@@ -334,37 +468,34 @@ func getWorkItemIDs(config Configuration, ctx context.Context) ([]int, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	// Extract work item IDs
-	lenIds := len(*queryResult.WorkItems)
-	if lenIds == 0 {
-		return []int{}, fmt.Errorf("was not able to fetch Work Item Ids, check the quert: %v", wiqlData)
-	}
-
-	//allIDs := [lenIds]int[]{}
-	var allIDs [20000]int
-	if queryResult.WorkItems != nil {
-		for i, workItem := range *queryResult.WorkItems {
-			allIDs[i] = *workItem.Id
-		}
-	} else {
-		log.Fatal("Can not fetch work item ids")
+	if queryResult.WorkItems == nil {
+		return nil, fmt.Errorf("WIQL result has no work items list, check the query: %s", query)
 	}
 
 	// Check if there are more results
 	if queryResult.WorkItemRelations != nil && len(*queryResult.WorkItemRelations) != 0 {
-		log.Fatal("Unexpected status: Length of the WorkItemRelations is not 0")
+		return nil, fmt.Errorf("unexpected WIQL result: %d work item relations, expected a flat query", len(*queryResult.WorkItemRelations))
 	}
 
-	return allIDs[0:lenIds], nil
+	// Extract work item IDs
+	ids := make([]int, 0, len(*queryResult.WorkItems))
+	for _, workItem := range *queryResult.WorkItems {
+		ids = append(ids, *workItem.Id)
+	}
+	return ids, nil
 }
-func getClient() http.Client {
-	return http.Client{Timeout: 10 * time.Second}
+
+// Azure DevOps services URL, BaseURL points the calls to another server.
+func (config Configuration) baseURL() string {
+	if config.BaseURL != "" {
+		return strings.TrimSuffix(config.BaseURL, "/") + "/"
+	}
+	return "https://dev.azure.com/"
 }
 
 func createRequest(config Configuration, ctx context.Context, RequestPath string, httpMethod string, body io.Reader, contentType string) (*http.Request, error) {
 
-	requestUrl := "https://dev.azure.com/" + config.OrganizationName + "/" + config.ProjectName + "/_apis/" + RequestPath
+	requestUrl := config.baseURL() + config.OrganizationName + "/" + config.ProjectName + "/_apis/" + RequestPath
 	AuthHeaderValue := "Basic " + basicAuth(config.PersonalAccessToken)
 
 	req, err := http.NewRequestWithContext(ctx, httpMethod, requestUrl, body)
@@ -382,175 +513,185 @@ func DownloadAllWits(config Configuration, dstFilePath string) error {
 	ctx := context.Background()
 
 	// Fetch work item IDs in batches using WIQL
-	WitIds, err := getWorkItemIDs(config, ctx)
+	condition, err := wiqlCondition(config)
 	if err != nil {
 		return err
 	}
-
-	//todo: remove
-	//WitIds = WitIds[:400]
-	//todo: end remove
-	BulckSize := 50
-	WitCount := len(WitIds)
-	channelsCount := WitCount / BulckSize
-	if BulckSize*channelsCount < WitCount {
-		channelsCount += 1
-	}
-
-	channels := make([]chan *[]workitemtracking.WorkItem, channelsCount)
-	for chanIndex := range channels {
-		channels[chanIndex] = make(chan *[]workitemtracking.WorkItem)
+	WitIds, err := getWorkItemIDs(config, ctx, condition, time.Time{})
+	if err != nil {
+		return err
 	}
 
-	i := 0
-	for i < WitCount {
-		log.Printf("Entering loop with i: %d\n", i)
-		endIndex := i + BulckSize
-		log.Printf("loop i:%d, endIndex: %d\n", i, endIndex)
-		if i+BulckSize >= WitCount {
-			endIndex = WitCount - 1
-		}
-
-		log.Printf("loop i:%d, endIndex after cahnge: %d\n", i, endIndex)
-		log.Printf("loop i: %d, endIndex:%d, i/BulckSize: %d\n", i, endIndex, i/BulckSize)
-		if i/BulckSize == 8 {
-			log.Printf("Problem loop i: %d, endIndex:%d, i/BulckSize: %d\n", i, endIndex, i/BulckSize)
-
-		}
-		WitIdsSlice := WitIds[i:endIndex]
-		chanIndex := i / BulckSize
-		go func() {
-			GetWorkItemsBySlice(config, ctx, WitIdsSlice, channels[chanIndex])
-		}()
-
-		i += BulckSize
+	AllWits, err := GetWorkItems(config, ctx, WitIds)
+	if err != nil {
+		return err
 	}
-
-	//fmt.Printf("queryResult.WorkItems: %v\n", *(.Id)
-	//*[]workitemtracking.WorkItem
-
-	AllWits := []workitemtracking.WorkItem{}
-	for j, ch := range channels {
-		fmt.Printf("fetched from chanel %d out of %d channels\n", j, len(channels))
-		IterationWorkItems := <-ch
-		AllWits = append(AllWits, *IterationWorkItems...)
-
+	AllWits, err = addParentWorkItems(config, ctx, AllWits)
+	if err != nil {
+		return err
 	}
 	fmt.Printf("IterationWorkItems: %d\n", len(AllWits))
-	err = CacheToFile(&AllWits, dstFilePath)
+	return CacheToFile(&AllWits, dstFilePath)
+}
+
+// Download the work items with the given IDs, in the DownloadAllWits file format.
+func DownloadWits(config Configuration, WitIds []int, dstFilePath string) error {
+	wits, err := GetWorkItems(config, context.Background(), WitIds)
 	if err != nil {
 		return err
 	}
-	return nil
+	return CacheToFile(&wits, dstFilePath)
 }
 
-func GetWorkItemsBySlice(config Configuration, ctx context.Context, WitIds []int, ch chan *[]workitemtracking.WorkItem) error {
-	retWorkItems := []workitemtracking.WorkItem{}
+// Fetch the work items in batches of workItemsBatchSize, in the order of the IDs.
+// Batches run in parallel up to the client concurrency limit, the first failed
+// batch cancels the rest and its error is returned. Deleted IDs are omitted.
+func GetWorkItems(config Configuration, ctx context.Context, WitIds []int) ([]workitemtracking.WorkItem, error) {
+	group, ctx := newErrGroup(ctx)
+	batches := make([][]workitemtracking.WorkItem, (len(WitIds)+workItemsBatchSize-1)/workItemsBatchSize)
+	for index := range batches {
+		start := index * workItemsBatchSize
+		end := min(start+workItemsBatchSize, len(WitIds))
+		group.Go(func() error {
+			wits, err := getWorkItemsBatch(config, ctx, WitIds[start:end])
+			if err != nil {
+				return fmt.Errorf("work items %d-%d of %d: %w", start, end, len(WitIds), err)
+			}
+			log.Printf("fetched work items %d-%d of %d\n", start, end, len(WitIds))
+			batches[index] = wits
+			return nil
+		})
+	}
+	err := group.Wait()
+	if err != nil {
+		return nil, err
+	}
 
-	for i, WitId := range WitIds {
-		fmt.Printf("fetched witid  : %d/%d\n", i, len(WitIds))
-		req, err := createRequest(config, ctx, fmt.Sprintf("wit/workitems/%d?$expand=all&api-version=7.0", WitId), http.MethodGet, nil, "application/json")
-		if err != nil {
-			return err
-		}
-		client := getClient()
+	AllWits := []workitemtracking.WorkItem{}
+	for _, wits := range batches {
+		AllWits = append(AllWits, wits...)
+	}
+	return AllWits, nil
+}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			log.Printf("received error in HTTP clinet request: %v", err)
-			return err
+// Add the parents missing from the work items, the daily report shows a work
+// item with its parent even when the parent is in another sprint.
+func addParentWorkItems(config Configuration, ctx context.Context, wits []workitemtracking.WorkItem) ([]workitemtracking.WorkItem, error) {
+	known := map[int]bool{}
+	for _, wit := range wits {
+		known[*wit.Id] = true
+	}
+	parentIds := []int{}
+	for _, wit := range wits {
+		parentId, ok := workItemParentId(wit)
+		if ok && !known[parentId] {
+			known[parentId] = true
+			parentIds = append(parentIds, parentId)
 		}
-		defer resp.Body.Close()
+	}
+	if len(parentIds) == 0 {
+		return wits, nil
+	}
+	slices.Sort(parentIds)
+	parents, err := GetWorkItems(config, ctx, parentIds)
+	if err != nil {
+		return nil, fmt.Errorf("parent work items: %w", err)
+	}
+	log.Printf("fetched %d parent work items\n", len(parents))
+	return append(wits, parents...), nil
+}
 
-		// Check the status code
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("HTTP status error: %d %s", resp.StatusCode, resp.Status)
+// The parent from the System.Parent field, or the hierarchy relation when the relations were expanded.
+func workItemParentId(wit workitemtracking.WorkItem) (int, bool) {
+	if wit.Fields != nil {
+		if parentId, ok := (*wit.Fields)["System.Parent"].(float64); ok {
+			return int(parentId), true
 		}
-
-		// Decode the JSON response
-		//var queryResult witWorkItemQueryResult
-		var wit workitemtracking.WorkItem
-		err = json.NewDecoder(resp.Body).Decode(&wit)
-		if err != nil {
-			return err
+	}
+	if wit.Relations != nil {
+		for _, relation := range *wit.Relations {
+			if relation.Rel == nil || *relation.Rel != "System.LinkTypes.Hierarchy-Reverse" || relation.Url == nil {
+				continue
+			}
+			parentId, err := strconv.Atoi(path.Base(*relation.Url))
+			if err == nil {
+				return parentId, true
+			}
 		}
-		retWorkItems = append(retWorkItems, wit)
 	}
+	return 0, false
+}
 
-	ch <- &retWorkItems
-	return nil
+// The largest number of IDs the workitemsbatch endpoint accepts.
+const workItemsBatchSize = 200
+
+// Fields downloaded when the configuration has none, the ones the work items are converted from.
+var DefaultFields = []string{
+	"System.Id", "System.Title", "System.WorkItemType", "System.State", "System.IterationPath", "System.AreaPath",
+	"System.AssignedTo", "System.CreatedBy", "System.ChangedDate", "System.Parent", "System.Tags",
+	"Microsoft.VSTS.Common.Priority", "Microsoft.VSTS.Scheduling.RemainingWork", "Microsoft.VSTS.Scheduling.CompletedWork",
 }
 
-func ReadWitsFromFile(filePath string) (wits []WorkItem, err error) {
-	data, err := os.ReadFile(filePath)
+// Azure DevOps rejects a field list together with an expand, ExpandRelations downloads every field.
+func getWorkItemsBatch(config Configuration, ctx context.Context, WitIds []int) ([]workitemtracking.WorkItem, error) {
+	batchRequest := map[string]any{"ids": WitIds, "errorPolicy": "omit"}
+	if config.ExpandRelations {
+		batchRequest["$expand"] = "relations"
+	} else if len(config.Fields) > 0 {
+		batchRequest["fields"] = config.Fields
+	} else {
+		batchRequest["fields"] = DefaultFields
+	}
+	postData, err := json.Marshal(batchRequest)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling JSON: %v", err)
+	}
+	req, err := createRequest(config, ctx, "wit/workitemsbatch?api-version=7.0", http.MethodPost, bytes.NewBuffer(postData), "application/json")
 	if err != nil {
 		return nil, err
 	}
+	client := getClient(config)
 
-	err = json.Unmarshal(data, &wits)
+	resp, err := client.Do(req)
 	if err != nil {
+		log.Printf("received error in HTTP clinet request: %v", err)
 		return nil, err
 	}
-	return wits, nil
-}
+	defer resp.Body.Close()
 
-func SubmitSprintStatus(config Configuration, requestDicts []*(map[string]string)) error {
-	// Provision parents
-	// todo: Clean new identical parents by title
-	for _, requestDict := range requestDicts {
-		if (*requestDict)["ChildrenIDs"] != "" || (*requestDict)["ParentID"] == "-1" {
-			continue
-		}
-		if _, err := strconv.Atoi((*requestDict)["ParentID"]); err != nil {
-			return err
-		}
+	// Check the status code
+	if resp.StatusCode != http.StatusOK {
+		return nil, newResponseError(resp)
 	}
-	workerIds := make(map[string]string)
-
-	for _, requestDict := range requestDicts {
 
-		strWorkerValue, ok := workerIds[(*requestDict)["WorkerID"]]
-		if !ok {
-			strWorker, strWorkerOK := (*requestDict)["WorkerID"]
-			if !strWorkerOK {
-				return fmt.Errorf("no Key WorkerID in %v", *requestDict)
-			}
-			if strWorker == "" {
-				return fmt.Errorf("no WorkerID provided in %v", *requestDict)
-			}
-			workerIds[(*requestDict)["WorkerID"]] = getWorker(strWorker)
-			strWorkerValue = workerIds[(*requestDict)["WorkerID"]]
-		}
-		(*requestDict)["WorkerID"] = strWorkerValue
-
-		if (*requestDict)["ChildrenIDs"] == "" {
-			continue
-		}
-		err := ProvisionWitFromDict(config, requestDict)
-		if err != nil {
-			return err
-		}
+	// Omitted work items are null in the value list.
+	var batchResult struct {
+		Value []*workitemtracking.WorkItem `json:"value"`
 	}
-
-	for _, requestDict := range requestDicts {
-		if (*requestDict)["ChildrenIDs"] != "" {
-			continue
+	err = json.NewDecoder(resp.Body).Decode(&batchResult)
+	if err != nil {
+		return nil, err
+	}
+	wits := []workitemtracking.WorkItem{}
+	for _, wit := range batchResult.Value {
+		if wit != nil {
+			wits = append(wits, *wit)
 		}
+	}
+	return wits, nil
+}
 
-		err := ProvisionWitFromDict(config, requestDict)
-		if err != nil {
-			return err
-		}
-		if (*requestDict)["ParentID"] == "-1"{
-			continue
-		}
+func ReadWitsFromFile(filePath string) (wits []WorkItem, err error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
 
-		err = setWitParentFromWit(config, requestDict)
-		if err != nil {
-			return err
-		}
+	err = json.Unmarshal(data, &wits)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return wits, nil
 }
 
 func ProvisionWitFromDict(config Configuration, requestDict *(map[string]string)) error {
@@ -572,7 +713,7 @@ func CreateWit(config Configuration, requestDict *(map[string]string)) error {
 		log.Printf("received error in Generate Create Wit Request: %v", err)
 		return err
 	}
-	client := getClient()
+	client := getClient(config)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -583,7 +724,7 @@ func CreateWit(config Configuration, requestDict *(map[string]string)) error {
 
 	// Check the status code
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP status error: %d %s", resp.StatusCode, resp.Status)
+		return newResponseError(resp)
 	}
 
 	// Decode the JSON response
@@ -612,6 +753,10 @@ func GenerateCreateWitRequest(config Configuration, requestDict *(map[string]str
 		dictRequest["Sprint"] = wobject.Sprint
 		dictRequest["Status"] = wobject.Status
 		dictRequest["Type"] = wobject.Type
+		dictRequest["TypeUrlName"] = wobjectType.UrlName
+		dictRequest["State"] = states.State(wobject.Type, wobject.Status)
+		dictRequest["Field:System.Reason"] = transition field of the new State
+		dictRequest["History"] = dated comment posted to the discussion
 	*/
 
 	if config.AreaPath == "" {
@@ -625,15 +770,10 @@ func GenerateCreateWitRequest(config Configuration, requestDict *(map[string]str
 	ctx := context.Background()
 	postList := []map[string]string{}
 
-	var witUrlType string
-	switch {
-	case (*requestDict)["Type"] == "UserStory":
-		witUrlType = "$User%20Story"
-	case (*requestDict)["Type"] == "Task" || (*requestDict)["Type"] == "Bug":
-		witUrlType = "$" + (*requestDict)["Type"]
-	default:
+	if (*requestDict)["TypeUrlName"] == "" {
 		return nil, fmt.Errorf("unknown WIT Type: %s", (*requestDict)["Type"])
 	}
+	witUrlType := "$" + url.PathEscape((*requestDict)["TypeUrlName"])
 
 	postList = append(postList, map[string]string{
 		"op":    "add",
@@ -647,11 +787,15 @@ func GenerateCreateWitRequest(config Configuration, requestDict *(map[string]str
 		"value": (*requestDict)["Title"],
 	})
 
-	postList = append(postList, map[string]string{
-		"op":    "add",
-		"path":  "/fields/System.Description",
-		"value": (*requestDict)["Description"],
-	})
+	if (*requestDict)["State"] != "" {
+		postList = append(postList, map[string]string{
+			"op":    "add",
+			"path":  "/fields/System.State",
+			"value": (*requestDict)["State"],
+		})
+	}
+	fillRequestFields(&postList, *requestDict)
+	fillRequestHistory(&postList, *requestDict)
 
 	iteration, err := GetIteration(config)
 	if err != nil {
@@ -735,28 +879,28 @@ func UpdateWit(config Configuration, requestDict map[string]string) error {
 		log.Printf("received error in Generate Create Wit Request: %v", err)
 		return err
 	}
-	return Patch(req)
+	return Patch(config, req)
 
 }
 
-func setWitParentFromWit(config Configuration, requestDict *map[string]string) error {
+func SetWitParent(config Configuration, requestDict *map[string]string) error {
 	/*
-	            logger.info(f"Removing parent: {wit_id}")
-            request_remove_parent = [{"op": "remove", "path": "/relations/0"}]
-            self.patch(url, request_remove_parent)
+		            logger.info(f"Removing parent: {wit_id}")
+	            request_remove_parent = [{"op": "remove", "path": "/relations/0"}]
+	            self.patch(url, request_remove_parent)
 
-            logger.info(f"Adding parent: {wit_id}")
-            return self.patch(url, request_data)
+	            logger.info(f"Adding parent: {wit_id}")
+	            return self.patch(url, request_data)
 	*/
 	ctx := context.Background()
 	postList := []map[string]any{}
 
-	 postList = append(postList, map[string]any{
-		"op": "add",
+	postList = append(postList, map[string]any{
+		"op":   "add",
 		"path": "/relations/-",
 		"value": map[string]string{
 			"rel": "System.LinkTypes.Hierarchy-Reverse",
-			"url": fmt.Sprintf("https://dev.azure.com/%s/%s/_apis/wit/workItems/%s", config.OrganizationName, config.ProjectName, (*requestDict)["ParentID"]),
+			"url": fmt.Sprintf("%s%s/%s/_apis/wit/workItems/%s", config.baseURL(), config.OrganizationName, config.ProjectName, (*requestDict)["ParentID"]),
 		},
 	})
 
@@ -772,12 +916,12 @@ func setWitParentFromWit(config Configuration, requestDict *map[string]string) e
 		log.Printf("received error in Generate Create Wit Request: %v", err)
 		return err
 	}
-	return Patch(req)
+	return Patch(config, req)
 
 }
-func Patch(req *http.Request) error {
+func Patch(config Configuration, req *http.Request) error {
 
-	client := getClient()
+	client := getClient(config)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -788,7 +932,7 @@ func Patch(req *http.Request) error {
 
 	// Check the status code
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP status error: %d %s", resp.StatusCode, resp.Status)
+		return newResponseError(resp)
 	}
 
 	// Decode the JSON response
@@ -816,6 +960,10 @@ func GenerateUpdateWitRequest(config Configuration, requestDict map[string]strin
 		dictRequest["Sprint"] = wobject.Sprint
 		dictRequest["Status"] = wobject.Status
 		dictRequest["Type"] = wobject.Type
+		dictRequest["Rev"] = strconv.Itoa(wobject.Rev)
+		dictRequest["State"] = states.State(wobject.Type, wobject.Status)
+		dictRequest["Field:System.Reason"] = transition field of the new State
+		dictRequest["History"] = dated comment posted to the discussion
 	*/
 
 	if config.AreaPath == "" {
@@ -837,6 +985,16 @@ func GenerateUpdateWitRequest(config Configuration, requestDict map[string]strin
 		"value": requestDict["Title"],
 	})
 
+	if requestDict["State"] != "" {
+		postList = append(postList, map[string]string{
+			"op":    "add",
+			"path":  "/fields/System.State",
+			"value": requestDict["State"],
+		})
+	}
+	fillRequestFields(&postList, requestDict)
+	fillRequestHistory(&postList, requestDict)
+
 	if requestDict["Priority"] != "-1" {
 		postList = append(postList, map[string]string{
 			"op":    "add",
@@ -867,7 +1025,16 @@ func GenerateUpdateWitRequest(config Configuration, requestDict map[string]strin
 		"value": requestDict["WorkerID"],
 	})
 
-	postData, err := json.Marshal(postList)
+	// The test operation makes the whole patch fail if the work item changed since the revision was read.
+	patch := []interface{}{}
+	if rev, err := strconv.Atoi(requestDict["Rev"]); err == nil && rev > 0 {
+		patch = append(patch, map[string]interface{}{"op": "test", "path": "/rev", "value": rev})
+	}
+	for _, operation := range postList {
+		patch = append(patch, operation)
+	}
+
+	postData, err := json.Marshal(patch)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling JSON: %v", err)
 	}
@@ -878,6 +1045,36 @@ func GenerateUpdateWitRequest(config Configuration, requestDict map[string]strin
 	return req, err
 }
 
+// Add the fields set by FieldKeyPrefix keys, sorted to keep the patch stable.
+func fillRequestFields(postList *[]map[string]string, requestDict map[string]string) {
+	keys := []string{}
+	for key := range requestDict {
+		if strings.HasPrefix(key, FieldKeyPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		*postList = append(*postList, map[string]string{
+			"op":    "add",
+			"path":  "/fields/" + strings.TrimPrefix(key, FieldKeyPrefix),
+			"value": requestDict[key],
+		})
+	}
+}
+
+// The history value is added to the work item discussion, it does not replace earlier entries.
+func fillRequestHistory(postList *[]map[string]string, requestDict map[string]string) {
+	if requestDict["History"] == "" {
+		return
+	}
+	*postList = append(*postList, map[string]string{
+		"op":    "add",
+		"path":  "/fields/System.History",
+		"value": requestDict["History"],
+	})
+}
+
 func fillUpdateWitRequestTimes(postList *[]map[string]string, requestDict map[string]string) error {
 
 	if requestDict["LeftTime"] != "-1" {
@@ -909,15 +1106,17 @@ func fillUpdateWitRequestTimes(postList *[]map[string]string, requestDict map[st
 	return nil
 }
 
-func getWorker(uniqueNamePart string) string {
+// Convert a worker id like "name.surname" to the "Name Surname" display name.
+func GetWorker(uniqueNamePart string) (string, error) {
 	// data = workItem.Fields["System.AssignedTo"].(map[string]interface{})["uniqueName"].(string)
 	ret := strings.Split(uniqueNamePart, ".")
+	if len(ret) < 2 || ret[0] == "" || ret[1] == "" {
+		return "", fmt.Errorf("worker id '%s' is not in the 'name.surname' format", uniqueNamePart)
+	}
 	nameRunes := []rune(ret[0])
 	nameRunes[0] = unicode.ToUpper(nameRunes[0])
 
 	lastNameRunes := []rune(ret[1])
 	lastNameRunes[0] = unicode.ToUpper(lastNameRunes[0])
-	return string(nameRunes) + " " + string(lastNameRunes)
-
+	return string(nameRunes) + " " + string(lastNameRunes), nil
 }
-
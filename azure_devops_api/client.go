@@ -0,0 +1,273 @@
+package azure_devops_api
+
+import (
+	"context"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client settings used when the configuration leaves them at zero.
+const (
+	defaultMaxConcurrency        = 4
+	defaultMaxRetries            = 5
+	defaultRequestTimeoutSeconds = 30
+)
+
+// Client is the HTTP client shared by the REST calls of a configuration. At
+// most MaxConcurrency requests run at once. Throttled (429, 503) requests are
+// retried after Retry-After or an exponential backoff; transport errors and
+// other server errors only for requests that are safe to repeat. When
+// Azure DevOps reports the rate limit as exhausted, X-RateLimit-Remaining 0, or
+// asks to slow down with Retry-After on a successful response, every request
+// of the client waits until the limit resets.
+type Client struct {
+	httpClient *http.Client
+	slots      chan struct{}
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	sleep      func(ctx context.Context, delay time.Duration) error
+
+	mutex       sync.Mutex
+	pausedUntil time.Time
+}
+
+func NewClient(maxConcurrency int, maxRetries int, timeout time.Duration) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: timeout},
+		slots:      make(chan struct{}, maxConcurrency),
+		maxRetries: maxRetries,
+		baseDelay:  time.Second,
+		maxDelay:   time.Minute,
+		sleep:      sleepContext,
+	}
+}
+
+type clientSettings struct {
+	maxConcurrency int
+	maxRetries     int
+	timeout        time.Duration
+}
+
+var (
+	clientsMutex sync.Mutex
+	clients      = map[clientSettings]*Client{}
+)
+
+// The client shared by every call with the same client settings, so the concurrency limit is global.
+func getClient(config Configuration) *Client {
+	settings := clientSettings{defaultMaxConcurrency, defaultMaxRetries, defaultRequestTimeoutSeconds * time.Second}
+	if config.MaxConcurrency > 0 {
+		settings.maxConcurrency = config.MaxConcurrency
+	}
+	if config.MaxRetries != 0 {
+		settings.maxRetries = max(config.MaxRetries, 0)
+	}
+	if config.RequestTimeoutSeconds > 0 {
+		settings.timeout = time.Duration(config.RequestTimeoutSeconds) * time.Second
+	}
+
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+	client, ok := clients[settings]
+	if !ok {
+		client = NewClient(settings.maxConcurrency, settings.maxRetries, settings.timeout)
+		clients[settings] = client
+	}
+	return client
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Send the request, retrying it as described on Client. The request body is
+// replayed from req.GetBody, requests without it are sent once.
+func (client *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	select {
+	case client.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-client.slots }()
+
+	for attempt := 0; ; attempt++ {
+		err := client.waitRateLimit(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if attempt > 0 && req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := client.httpClient.Do(req)
+		canRetry := attempt < client.maxRetries && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+		if err != nil {
+			if !canRetry || !isIdempotent(req) || ctx.Err() != nil {
+				return nil, err
+			}
+			delay := client.backoff(attempt)
+			log.Printf("%s %s failed: %v, retrying in %v\n", req.Method, req.URL.Path, err, delay)
+			err = client.sleep(ctx, delay)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		client.updateRateLimit(resp)
+		if !canRetry || !shouldRetry(req, resp.StatusCode) {
+			return resp, nil
+		}
+		delay, ok := retryAfter(resp.Header, time.Now())
+		if !ok {
+			delay = client.backoff(attempt)
+		}
+		delay = min(delay, client.maxDelay)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		log.Printf("%s %s returned %s, retrying in %v\n", req.Method, req.URL.Path, resp.Status, delay)
+		err = client.sleep(ctx, delay)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Exponential backoff with jitter, capped at maxDelay.
+func (client *Client) backoff(attempt int) time.Duration {
+	delay := client.baseDelay << min(attempt, 16)
+	if delay <= 0 || delay > client.maxDelay {
+		delay = client.maxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (client *Client) waitRateLimit(ctx context.Context) error {
+	client.mutex.Lock()
+	delay := time.Until(client.pausedUntil)
+	client.mutex.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	log.Printf("Azure DevOps rate limit reached, waiting %v\n", delay.Round(time.Second))
+	return client.sleep(ctx, delay)
+}
+
+// Pause the client when a successful response says the rate limit is exhausted.
+// Throttled responses are retried by Do.
+func (client *Client) updateRateLimit(resp *http.Response) {
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		return
+	}
+	now := time.Now()
+	delay, ok := retryAfter(resp.Header, now)
+	if !ok && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		delay, ok = rateLimitReset(resp.Header, now)
+	}
+	if !ok || delay <= 0 {
+		return
+	}
+	delay = min(delay, client.maxDelay)
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if now.Add(delay).After(client.pausedUntil) {
+		client.pausedUntil = now.Add(delay)
+	}
+}
+
+// Retry-After in seconds or as an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// X-RateLimit-Reset is the Unix time the limit resets at.
+func rateLimitReset(header http.Header, now time.Time) (time.Duration, bool) {
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return max(time.Unix(reset, 0).Sub(now), 0), true
+}
+
+// Throttled requests were not processed and are always retried. Server errors
+// may have been processed, only reads are repeated.
+func shouldRetry(req *http.Request, statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(req)
+	}
+	return false
+}
+
+// Reads, including the WIQL and batch queries sent as POST. Creates and patches are not repeated.
+func isIdempotent(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	return req.Method == http.MethodPost && (strings.Contains(req.URL.Path, "/wiql") || strings.Contains(req.URL.Path, "/workitemsbatch"))
+}
+
+// errGroup runs functions in goroutines and keeps the first error, which
+// cancels the context of the others. Like golang.org/x/sync/errgroup.
+type errGroup struct {
+	group  sync.WaitGroup
+	once   sync.Once
+	err    error
+	cancel context.CancelFunc
+}
+
+func newErrGroup(ctx context.Context) (*errGroup, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &errGroup{cancel: cancel}, ctx
+}
+
+func (group *errGroup) Go(run func() error) {
+	group.group.Add(1)
+	go func() {
+		defer group.group.Done()
+		err := run()
+		if err != nil {
+			group.once.Do(func() {
+				group.err = err
+				group.cancel()
+			})
+		}
+	}()
+}
+
+// Wait for every function, the first error is returned.
+func (group *errGroup) Wait() error {
+	group.group.Wait()
+	group.cancel()
+	return group.err
+}
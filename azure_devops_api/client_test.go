@@ -0,0 +1,142 @@
+package azure_devops_api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(maxConcurrency int, maxRetries int) (*Client, *[]time.Duration) {
+	client := NewClient(maxConcurrency, maxRetries, 5*time.Second)
+	delays := &[]time.Duration{}
+	var mutex sync.Mutex
+	client.sleep = func(ctx context.Context, delay time.Duration) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		*delays = append(*delays, delay)
+		return nil
+	}
+	return client, delays
+}
+
+func TestClient(t *testing.T) {
+	t.Run("Retry throttled requests", func(t *testing.T) {
+		bodies := []string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			switch len(bodies) {
+			case 1:
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(http.StatusTooManyRequests)
+			case 2:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			default:
+				w.Write([]byte("{}"))
+			}
+		}))
+		defer server.Close()
+
+		client, delays := newTestClient(1, 3)
+		req, _ := http.NewRequest(http.MethodPatch, server.URL+"/wit/workitems/1", strings.NewReader(`[{"op": "add"}]`))
+		resp, err := client.Do(req)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("Do() = %v, %v", resp, err)
+		}
+		resp.Body.Close()
+		if len(bodies) != 3 || bodies[2] != `[{"op": "add"}]` {
+			t.Errorf("Do() sent bodies %q", bodies)
+		}
+		if len(*delays) != 2 || (*delays)[0] != 7*time.Second || (*delays)[1] > 2*time.Second {
+			t.Errorf("Do() delays = %v, want Retry-After then backoff", *delays)
+		}
+	})
+
+	t.Run("Server errors", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		client, _ := newTestClient(1, 2)
+		req, _ := http.NewRequest(http.MethodPatch, server.URL+"/wit/workitems/1", strings.NewReader("[]"))
+		resp, err := client.Do(req)
+		if err != nil || resp.StatusCode != http.StatusInternalServerError || requests.Load() != 1 {
+			t.Errorf("Do() of a patch = %v, %v after %d requests, want one request", resp, err, requests.Load())
+		}
+
+		requests.Store(0)
+		req, _ = http.NewRequest(http.MethodPost, server.URL+"/wit/workitemsbatch", strings.NewReader("{}"))
+		resp, err = client.Do(req)
+		if err != nil || resp.StatusCode != http.StatusInternalServerError || requests.Load() != 3 {
+			t.Errorf("Do() of a batch read = %v, %v after %d requests, want 3 requests", resp, err, requests.Load())
+		}
+	})
+
+	t.Run("Rate limit", func(t *testing.T) {
+		reset := time.Now().Add(20 * time.Second).Unix()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		}))
+		defer server.Close()
+
+		client, delays := newTestClient(1, 0)
+		for range 2 {
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+		}
+		if len(*delays) != 1 || (*delays)[0] < 15*time.Second || (*delays)[0] > 20*time.Second {
+			t.Errorf("Do() delays = %v, want a wait until the reset", *delays)
+		}
+	})
+
+	t.Run("Concurrency limit", func(t *testing.T) {
+		var running, maxRunning atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				seen := maxRunning.Load()
+				if current <= seen || maxRunning.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		client, _ := newTestClient(2, 0)
+		var group sync.WaitGroup
+		for range 8 {
+			group.Add(1)
+			go func() {
+				defer group.Done()
+				req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+				resp, err := client.Do(req)
+				if err != nil {
+					t.Errorf("Do() error = %v", err)
+					return
+				}
+				resp.Body.Close()
+			}()
+		}
+		group.Wait()
+		if maxRunning.Load() > 2 {
+			t.Errorf("Do() ran %d requests at once, want at most 2", maxRunning.Load())
+		}
+	})
+}
@@ -0,0 +1,322 @@
+package azure_devops_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer serves the WIQL and workitemsbatch endpoints of the "org/project" project.
+type fakeServer struct {
+	mutex sync.Mutex
+	// Work item revisions by ID, the area content.
+	revs map[int]int
+	// ChangedDate by ID in the server clock, unset items changed at fakeEpoch.
+	changedDates map[int]time.Time
+	// Batches containing the ID fail.
+	failId int
+	// IDs requested from workitemsbatch.
+	fetchedIds []int
+	// WIQL queries run.
+	queries []string
+	// Iteration paths and parent IDs by ID, unset items are in no sprint and have no parent.
+	sprints map[int]string
+	parents map[int]int
+}
+
+// Server time of the fake, behind the local clock.
+var fakeEpoch = time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+
+var wiqlChangedDateCondition = regexp.MustCompile(`\[System\.ChangedDate\] > '([^']*)'`)
+var wiqlIdCondition = regexp.MustCompile(`\[System\.Id\] > (\d+) ORDER BY \[System\.Id\]`)
+var wiqlIterationCondition = regexp.MustCompile(`\[System\.IterationPath\] = '([^']*)'`)
+
+func (server *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	switch r.URL.Path {
+	case "/org/project/_apis/wit/wiql":
+		var query struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&query)
+		server.queries = append(server.queries, query.Query)
+		ids := []int{}
+		for id := range server.revs {
+			ids = append(ids, id)
+		}
+		if match := wiqlChangedDateCondition.FindStringSubmatch(query.Query); match != nil {
+			since, _ := time.Parse(time.RFC3339, match[1])
+			ids = slices.DeleteFunc(ids, func(id int) bool { return !server.changedDate(id).After(since) })
+		}
+		if match := wiqlIterationCondition.FindStringSubmatch(query.Query); match != nil {
+			ids = slices.DeleteFunc(ids, func(id int) bool { return server.sprints[id] != match[1] })
+		}
+		slices.Sort(ids)
+		if match := wiqlIdCondition.FindStringSubmatch(query.Query); match != nil {
+			lastId, _ := strconv.Atoi(match[1])
+			ids = slices.DeleteFunc(ids, func(id int) bool { return id <= lastId })
+		}
+		top, err := strconv.Atoi(r.URL.Query().Get("$top"))
+		if err != nil {
+			top = 20000
+		}
+		if len(ids) > top && err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "VS402337: The number of work items returned exceeds the size limit of 20000."}`))
+			return
+		}
+		ids = ids[:min(len(ids), top)]
+		workItems := []map[string]int{}
+		for _, id := range ids {
+			workItems = append(workItems, map[string]int{"id": id})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"workItems": workItems})
+	case "/org/project/_apis/wit/workitemsbatch":
+		var batch struct {
+			Ids []int `json:"ids"`
+		}
+		json.NewDecoder(r.Body).Decode(&batch)
+		if len(batch.Ids) > workItemsBatchSize || slices.Contains(batch.Ids, server.failId) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "batch failed"}`))
+			return
+		}
+		server.fetchedIds = append(server.fetchedIds, batch.Ids...)
+		value := []any{}
+		for _, id := range batch.Ids {
+			rev, ok := server.revs[id]
+			if !ok {
+				value = append(value, nil)
+				continue
+			}
+			fields := map[string]any{"System.Title": fmt.Sprintf("item %d", id), "System.IterationPath": server.sprints[id],
+				"System.ChangedDate": server.changedDate(id).Format(time.RFC3339Nano)}
+			if parentId, ok := server.parents[id]; ok {
+				fields["System.Parent"] = parentId
+			}
+			value = append(value, map[string]any{"id": id, "rev": rev, "fields": fields})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"count": len(value), "value": value})
+	case "/org/project/_apis/work/teamsettings/iterations":
+		w.Write([]byte(`{"count": 2, "value": [{"name": "sp0", "path": "project\\sp0"}, {"name": "sp1", "path": "project\\sp1"}]}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (server *fakeServer) changedDate(id int) time.Time {
+	if changedDate, ok := server.changedDates[id]; ok {
+		return changedDate
+	}
+	return fakeEpoch
+}
+
+func newFakeServer(t *testing.T, count int) (*fakeServer, Configuration) {
+	server := &fakeServer{revs: map[int]int{}, failId: -1, changedDates: map[int]time.Time{}, sprints: map[int]string{}, parents: map[int]int{}}
+	for id := 1; id <= count; id++ {
+		server.revs[id] = 1
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	return server, Configuration{BaseURL: httpServer.URL, OrganizationName: "org", ProjectName: "project", SystemAreaID: "7", SprintName: "sp1",
+		DownloadScope: DownloadScopeArea, MaxRetries: -1, MaxConcurrency: 8}
+}
+
+func readDownloadedIds(t *testing.T, filePath string) []int {
+	wits, err := ReadWitsFromFile(filePath)
+	if err != nil {
+		t.Fatalf("ReadWitsFromFile() error = %v", err)
+	}
+	ids := []int{}
+	for _, wit := range wits {
+		ids = append(ids, wit.ID)
+	}
+	return ids
+}
+
+func runWithTimeout(t *testing.T, run func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- run() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(30 * time.Second):
+		t.Fatalf("download did not return, deadlocked")
+		return nil
+	}
+}
+
+func TestDownloadAllWits(t *testing.T) {
+	t.Run("Every work item", func(t *testing.T) {
+		// More than the 20000 IDs the query result used to be copied into.
+		server, config := newFakeServer(t, 20451)
+		dstFilePath := filepath.Join(t.TempDir(), "pre_report.json")
+		err := runWithTimeout(t, func() error { return DownloadAllWits(config, dstFilePath) })
+		if err != nil {
+			t.Fatalf("DownloadAllWits() error = %v", err)
+		}
+		ids := readDownloadedIds(t, dstFilePath)
+		if len(ids) != 20451 || ids[0] != 1 || ids[len(ids)-1] != 20451 || !slices.IsSorted(ids) {
+			t.Errorf("DownloadAllWits() downloaded %d work items, %v...%v", len(ids), ids[:min(len(ids), 3)], ids[max(len(ids)-3, 0):])
+		}
+		if len(server.fetchedIds) != 20451 {
+			t.Errorf("DownloadAllWits() fetched %d IDs, want each ID once", len(server.fetchedIds))
+		}
+		if len(server.queries) != 2 || !strings.Contains(server.queries[1], "[System.Id] > 20000 ") {
+			t.Errorf("DownloadAllWits() queries = %v, want 2 pages", server.queries)
+		}
+	})
+
+	t.Run("Query pages", func(t *testing.T) {
+		server, config := newFakeServer(t, 450)
+		config.WiqlPageSize = 100
+		for _, id := range []int{5, 250, 300} {
+			server.changedDates[id] = fakeEpoch.Add(time.Hour)
+		}
+		ids, err := getWorkItemIDs(config, context.Background(), "[System.AreaId] = 7", time.Time{})
+		if err != nil || len(ids) != 450 || ids[449] != 450 {
+			t.Errorf("getWorkItemIDs() = %d IDs, %v", len(ids), err)
+		}
+		if len(server.queries) != 5 {
+			t.Errorf("getWorkItemIDs() ran %d queries, want 5", len(server.queries))
+		}
+
+		server.queries = nil
+		config.WiqlPageSize = 2
+		ids, err = getWorkItemIDs(config, context.Background(), "[System.AreaId] = 7", fakeEpoch.Add(time.Minute))
+		if err != nil || !slices.Equal(ids, []int{5, 250, 300}) || len(server.queries) != 2 {
+			t.Errorf("getWorkItemIDs() of changed items = %v, %v in queries %v", ids, err, server.queries)
+		}
+	})
+
+	t.Run("Current sprint", func(t *testing.T) {
+		server, config := newFakeServer(t, 500)
+		config.DownloadScope = ""
+		for id := 301; id <= 500; id++ {
+			server.sprints[id] = "project\\sp1"
+		}
+		server.parents[301] = 7
+		server.parents[302] = 7
+		server.parents[303] = 9
+		server.parents[304] = 305
+		dstFilePath := filepath.Join(t.TempDir(), "pre_report.json")
+		err := DownloadAllWits(config, dstFilePath)
+		if err != nil {
+			t.Fatalf("DownloadAllWits() error = %v", err)
+		}
+		ids := readDownloadedIds(t, dstFilePath)
+		if len(ids) != 202 || ids[0] != 301 || !slices.Equal(ids[200:], []int{7, 9}) {
+			t.Errorf("DownloadAllWits() downloaded %d work items, parents %v", len(ids), ids[max(len(ids)-2, 0):])
+		}
+		if len(server.fetchedIds) != 202 {
+			t.Errorf("DownloadAllWits() fetched %d IDs, want the sprint and its parents", len(server.fetchedIds))
+		}
+	})
+
+	t.Run("Failed batch", func(t *testing.T) {
+		server, config := newFakeServer(t, 1000)
+		server.failId = 450
+		err := runWithTimeout(t, func() error { return DownloadAllWits(config, filepath.Join(t.TempDir(), "pre_report.json")) })
+		if err == nil || !strings.Contains(err.Error(), "work items 400-600 of 1000") || !strings.Contains(err.Error(), "batch failed") {
+			t.Errorf("DownloadAllWits() error = %v", err)
+		}
+	})
+
+	t.Run("Deleted work items", func(t *testing.T) {
+		server, config := newFakeServer(t, 5)
+		dstFilePath := filepath.Join(t.TempDir(), "snapshot.json")
+		delete(server.revs, 4)
+		err := DownloadWits(config, []int{2, 4, 5}, dstFilePath)
+		if err != nil {
+			t.Fatalf("DownloadWits() error = %v", err)
+		}
+		if ids := readDownloadedIds(t, dstFilePath); !slices.Equal(ids, []int{2, 5}) {
+			t.Errorf("DownloadWits() ids = %v, want [2 5]", ids)
+		}
+	})
+
+	t.Run("Incremental", func(t *testing.T) {
+		server, config := newFakeServer(t, 450)
+		dir := t.TempDir()
+		cacheFilePath := filepath.Join(dir, "cache.json")
+		dstFilePath := filepath.Join(dir, "pre_report.json")
+		err := DownloadChangedWits(config, cacheFilePath, dstFilePath)
+		if err != nil {
+			t.Fatalf("DownloadChangedWits() first sync error = %v", err)
+		}
+		if len(server.fetchedIds) != 450 {
+			t.Errorf("DownloadChangedWits() first sync fetched %d IDs, want 450", len(server.fetchedIds))
+		}
+
+		server.fetchedIds = nil
+		server.revs[7] = 2
+		server.revs[451] = 1
+		delete(server.revs, 3)
+		server.changedDates[7] = fakeEpoch.Add(time.Minute)
+		server.changedDates[451] = fakeEpoch.Add(time.Minute)
+		err = DownloadChangedWits(config, cacheFilePath, dstFilePath)
+		if err != nil {
+			t.Fatalf("DownloadChangedWits() error = %v", err)
+		}
+		slices.Sort(server.fetchedIds)
+		if !slices.Equal(server.fetchedIds, []int{7, 451}) {
+			t.Errorf("DownloadChangedWits() fetched %v, want the changed and new IDs", server.fetchedIds)
+		}
+		ids := readDownloadedIds(t, dstFilePath)
+		if len(ids) != 450 || slices.Contains(ids, 3) || ids[len(ids)-1] != 451 {
+			t.Errorf("DownloadChangedWits() downloaded %d work items, removed 3: %v", len(ids), !slices.Contains(ids, 3))
+		}
+		wits, err := ReadWitsFromFile(dstFilePath)
+		if err != nil || wits[5].ID != 7 || wits[5].Rev != 2 {
+			t.Errorf("DownloadChangedWits() did not update work item 7: %v, %v", wits[5], err)
+		}
+	})
+
+	t.Run("Server clock behind", func(t *testing.T) {
+		server, config := newFakeServer(t, 5)
+		dir := t.TempDir()
+		cacheFilePath := filepath.Join(dir, "cache.json")
+		dstFilePath := filepath.Join(dir, "pre_report.json")
+		err := DownloadChangedWits(config, cacheFilePath, dstFilePath)
+		if err != nil {
+			t.Fatalf("DownloadChangedWits() first sync error = %v", err)
+		}
+		cache, err := LoadWitCache(cacheFilePath)
+		if err != nil || !cache.SyncedAt.Equal(fakeEpoch) {
+			t.Fatalf("DownloadChangedWits() SyncedAt = %v, %v, want the latest server ChangedDate %v", cache.SyncedAt, err, fakeEpoch)
+		}
+
+		// Changed after the first sync in the server clock, long before the local time.
+		server.fetchedIds = nil
+		server.revs[3] = 2
+		server.changedDates[3] = fakeEpoch.Add(30 * time.Second)
+		err = DownloadChangedWits(config, cacheFilePath, dstFilePath)
+		if err != nil {
+			t.Fatalf("DownloadChangedWits() error = %v", err)
+		}
+		if !slices.Equal(server.fetchedIds, []int{3}) {
+			t.Errorf("DownloadChangedWits() fetched %v, want the item changed in the server clock", server.fetchedIds)
+		}
+		cache, err = LoadWitCache(cacheFilePath)
+		if err != nil || !cache.SyncedAt.Equal(fakeEpoch.Add(30*time.Second)) {
+			t.Errorf("DownloadChangedWits() SyncedAt = %v, %v", cache.SyncedAt, err)
+		}
+
+		server.fetchedIds = nil
+		err = DownloadChangedWits(config, cacheFilePath, dstFilePath)
+		if err != nil || len(server.fetchedIds) != 0 {
+			t.Errorf("DownloadChangedWits() without changes fetched %v, %v", server.fetchedIds, err)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package azure_devops_api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DownloadScope values, the work items downloaded when WiqlCondition is not set.
+const (
+	// The area work items in the SprintName iteration, the default.
+	DownloadScopeSprint = "sprint"
+	// Every work item of the area.
+	DownloadScopeArea = "area"
+)
+
+var scopeWiqlConditions = map[string]string{
+	DownloadScopeSprint: "[System.TeamProject] = {project} AND [System.AreaId] = {areaId} AND [System.IterationPath] = {iteration}",
+	DownloadScopeArea:   "[System.TeamProject] = {project} AND [System.AreaId] = {areaId}",
+}
+
+var wiqlPlaceholder = regexp.MustCompile(`\{(\w*)\}`)
+
+// The WHERE condition selecting the downloaded work items: WiqlCondition, the
+// condition of the DownloadScope by default, and every WiqlFilters entry.
+// Placeholders are replaced by WIQL literals, quoted where needed:
+//
+//	{project}   the ProjectName
+//	{areaId}    the SystemAreaID
+//	{areaPath}  the AreaPath
+//	{iteration} the iteration path of SprintName, read from the team settings
+//	{assignee}  the Assignee, @Me when it is not set
+//
+// For example "[System.AreaPath] UNDER {areaPath}", "[System.State] <> 'Removed'"
+// or "[System.IterationPath] = {iteration}".
+func wiqlCondition(config Configuration) (string, error) {
+	scope := config.DownloadScope
+	if scope == "" {
+		scope = DownloadScopeSprint
+	}
+	condition, ok := scopeWiqlConditions[scope]
+	if !ok {
+		return "", fmt.Errorf("unknown DownloadScope '%s', use '%s' or '%s'", scope, DownloadScopeSprint, DownloadScopeArea)
+	}
+	if config.WiqlCondition != "" {
+		condition = config.WiqlCondition
+	}
+	for _, filter := range config.WiqlFilters {
+		condition = fmt.Sprintf("(%s) AND (%s)", condition, filter)
+	}
+
+	errors := []string{}
+	values := map[string]string{}
+	condition = wiqlPlaceholder.ReplaceAllStringFunc(condition, func(placeholder string) string {
+		name := strings.Trim(placeholder, "{}")
+		value, ok := values[name]
+		if !ok {
+			var err error
+			value, err = wiqlPlaceholderValue(config, name)
+			if err != nil {
+				errors = append(errors, err.Error())
+				return placeholder
+			}
+			values[name] = value
+		}
+		return value
+	})
+	if len(errors) > 0 {
+		return "", fmt.Errorf("WIQL condition '%s': %s", condition, strings.Join(errors, ", "))
+	}
+	return condition, nil
+}
+
+func wiqlPlaceholderValue(config Configuration, name string) (string, error) {
+	switch name {
+	case "project":
+		return wiqlString(config.ProjectName), nil
+	case "areaId":
+		if strings.Trim(config.SystemAreaID, "0123456789") != "" || config.SystemAreaID == "" {
+			return "", fmt.Errorf("SystemAreaID '%s' is not a number", config.SystemAreaID)
+		}
+		return config.SystemAreaID, nil
+	case "areaPath":
+		if config.AreaPath == "" {
+			return "", fmt.Errorf("{areaPath} needs AreaPath")
+		}
+		return wiqlString(config.AreaPath), nil
+	case "iteration":
+		iteration, err := GetIteration(config)
+		if err != nil {
+			return "", fmt.Errorf("{iteration}: %v, set SprintName or DownloadScope '%s'", err, DownloadScopeArea)
+		}
+		return wiqlString(*iteration.Path), nil
+	case "assignee":
+		if config.Assignee == "" {
+			return "@Me", nil
+		}
+		return wiqlString(config.Assignee), nil
+	}
+	return "", fmt.Errorf("unknown placeholder '{%s}', use {project}, {areaId}, {areaPath}, {iteration} or {assignee}", name)
+}
+
+// WIQL string literal, single quotes are doubled.
+func wiqlString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,58 @@
+package azure_devops_api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWiqlCondition(t *testing.T) {
+	config := Configuration{ProjectName: "Horey's", SystemAreaID: "7", AreaPath: "Horey's\\Team", DownloadScope: DownloadScopeArea}
+	tests := []struct {
+		name      string
+		condition string
+		filters   []string
+		assignee  string
+		want      string
+	}{
+		{"Default", "", nil, "", "[System.TeamProject] = 'Horey''s' AND [System.AreaId] = 7"},
+		{"Filters", "", []string{"[System.State] <> 'Removed'", "[System.AssignedTo] = {assignee}"}, "",
+			"(([System.TeamProject] = 'Horey''s' AND [System.AreaId] = 7) AND ([System.State] <> 'Removed')) AND ([System.AssignedTo] = @Me)"},
+		{"Custom condition", "[System.AreaPath] UNDER {areaPath} AND [System.AssignedTo] = {assignee}", nil, "horey@example.com",
+			"[System.AreaPath] UNDER 'Horey''s\\Team' AND [System.AssignedTo] = 'horey@example.com'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := config
+			config.WiqlCondition = tt.condition
+			config.WiqlFilters = tt.filters
+			config.Assignee = tt.assignee
+			got, err := wiqlCondition(config)
+			if err != nil || got != tt.want {
+				t.Errorf("wiqlCondition() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("Sprint scope", func(t *testing.T) {
+		_, config := newFakeServer(t, 1)
+		config.DownloadScope = ""
+		got, err := wiqlCondition(config)
+		want := "[System.TeamProject] = 'project' AND [System.AreaId] = 7 AND [System.IterationPath] = 'project\\sp1'"
+		if err != nil || got != want {
+			t.Errorf("wiqlCondition() = %s, %v, want %s", got, err, want)
+		}
+		config.SprintName = "sp9"
+		_, err = wiqlCondition(config)
+		if err == nil || !strings.Contains(err.Error(), "was not able to find Iteration by name: sp9") {
+			t.Errorf("wiqlCondition() of an unknown sprint error = %v", err)
+		}
+	})
+
+	t.Run("Invalid placeholders", func(t *testing.T) {
+		config := Configuration{SystemAreaID: "7 OR 1=1", WiqlFilters: []string{"[System.Tags] CONTAINS {tag}"}, DownloadScope: DownloadScopeArea}
+		_, err := wiqlCondition(config)
+		if err == nil || !strings.Contains(err.Error(), "SystemAreaID '7 OR 1=1' is not a number") || !strings.Contains(err.Error(), "unknown placeholder '{tag}'") {
+			t.Errorf("wiqlCondition() error = %v", err)
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package azure_devops_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+// WitCache is the local copy of the area work items, by ID. SyncedAt is the
+// latest System.ChangedDate of the cached items, a server time, items changed
+// after it are fetched by the next sync. The local clock is not used, it may
+// run ahead of the server. Query is the project and WIQL condition the items
+// were selected by, the cache is rebuilt when it changes.
+type WitCache struct {
+	SyncedAt  time.Time                            `json:"SyncedAt"`
+	Query     string                               `json:"Query"`
+	WorkItems map[string]workitemtracking.WorkItem `json:"WorkItems"`
+}
+
+// A missing cache file is an empty cache.
+func LoadWitCache(filePath string) (*WitCache, error) {
+	cache := &WitCache{WorkItems: map[string]workitemtracking.WorkItem{}}
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, cache)
+	if err != nil {
+		return nil, fmt.Errorf("work item cache '%s': %v", filePath, err)
+	}
+	if cache.WorkItems == nil {
+		cache.WorkItems = map[string]workitemtracking.WorkItem{}
+	}
+	return cache, nil
+}
+
+func (cache *WitCache) Save(filePath string) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	tmpFilePath := filePath + ".tmp"
+	err = os.WriteFile(tmpFilePath, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFilePath, filePath)
+}
+
+// Add the fetched work items, an item replaces the cached one unless the cached Rev is newer.
+func (cache *WitCache) Update(wits []workitemtracking.WorkItem) {
+	for _, wit := range wits {
+		if wit.Id == nil {
+			continue
+		}
+		key := strconv.Itoa(*wit.Id)
+		if cached, ok := cache.WorkItems[key]; ok && cached.Rev != nil && wit.Rev != nil && *cached.Rev > *wit.Rev {
+			continue
+		}
+		cache.WorkItems[key] = wit
+	}
+}
+
+// Latest System.ChangedDate of the cached work items, zero when none has one.
+func (cache *WitCache) LatestChange() time.Time {
+	latest := time.Time{}
+	for _, wit := range cache.WorkItems {
+		if wit.Fields == nil {
+			continue
+		}
+		value, _ := (*wit.Fields)["System.ChangedDate"].(string)
+		changedDate, err := time.Parse(time.RFC3339, value)
+		if err == nil && changedDate.After(latest) {
+			latest = changedDate
+		}
+	}
+	return latest
+}
+
+// Drop the cached work items not in the area anymore: deleted or moved away.
+func (cache *WitCache) Retain(WitIds []int) {
+	keys := make(map[string]bool, len(WitIds))
+	for _, id := range WitIds {
+		keys[strconv.Itoa(id)] = true
+	}
+	for key := range cache.WorkItems {
+		if !keys[key] {
+			delete(cache.WorkItems, key)
+		}
+	}
+}
+
+// IDs of the work items missing from the cache.
+func (cache *WitCache) Missing(WitIds []int) []int {
+	missing := []int{}
+	for _, id := range WitIds {
+		if _, ok := cache.WorkItems[strconv.Itoa(id)]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// Cached work items sorted by ID.
+func (cache *WitCache) List() []workitemtracking.WorkItem {
+	wits := []workitemtracking.WorkItem{}
+	for _, wit := range cache.WorkItems {
+		wits = append(wits, wit)
+	}
+	slices.SortFunc(wits, func(a, b workitemtracking.WorkItem) int { return *a.Id - *b.Id })
+	return wits
+}
+
+// Download the area work items through the cache, in the DownloadAllWits file format.
+// The first sync downloads every item, later ones only the items changed since the
+// previous sync and the ones missing from the cache. The ID query runs every time
+// to drop the items that do not match the query anymore.
+func DownloadChangedWits(config Configuration, cacheFilePath string, dstFilePath string) error {
+	ctx := context.Background()
+	cache, err := LoadWitCache(cacheFilePath)
+	if err != nil {
+		return err
+	}
+	condition, err := wiqlCondition(config)
+	if err != nil {
+		return err
+	}
+	query := config.OrganizationName + "/" + config.ProjectName + ": " + condition
+	if cache.Query != query {
+		cache = &WitCache{Query: query, WorkItems: map[string]workitemtracking.WorkItem{}}
+	}
+
+	WitIds, err := getWorkItemIDs(config, ctx, condition, time.Time{})
+	if err != nil {
+		return err
+	}
+	cache.Retain(WitIds)
+
+	if len(cache.WorkItems) == 0 {
+		log.Printf("work item cache is empty, downloading %d work items\n", len(WitIds))
+		wits, err := GetWorkItems(config, ctx, WitIds)
+		if err != nil {
+			return err
+		}
+		cache.Update(wits)
+	} else {
+		changedIds, err := getWorkItemIDs(config, ctx, condition, cache.SyncedAt)
+		if err != nil {
+			return err
+		}
+		// Changed items are cached, unless they moved into the area after the ID query.
+		fetchIds := cache.Missing(WitIds)
+		for _, id := range changedIds {
+			if _, ok := cache.WorkItems[strconv.Itoa(id)]; ok {
+				fetchIds = append(fetchIds, id)
+			}
+		}
+		log.Printf("work item cache synced at %s, downloading %d changed work items\n", cache.SyncedAt.Format(time.RFC3339), len(fetchIds))
+		wits, err := GetWorkItems(config, ctx, fetchIds)
+		if err != nil {
+			return err
+		}
+		cache.Update(wits)
+	}
+
+	// Items changed while syncing have a later ChangedDate, the next sync fetches them again.
+	if latest := cache.LatestChange(); latest.After(cache.SyncedAt) {
+		cache.SyncedAt = latest
+	}
+	err = cache.Save(cacheFilePath)
+	if err != nil {
+		return err
+	}
+	// Parents outside the query are fetched every time, they are few.
+	wits, err := addParentWorkItems(config, ctx, cache.List())
+	if err != nil {
+		return err
+	}
+	return CacheToFile(&wits, dstFilePath)
+}
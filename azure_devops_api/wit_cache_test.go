@@ -0,0 +1,51 @@
+package azure_devops_api
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+func newTestWorkItem(id int, rev int) workitemtracking.WorkItem {
+	return workitemtracking.WorkItem{Id: &id, Rev: &rev}
+}
+
+func TestWitCache(t *testing.T) {
+	cacheFilePath := filepath.Join(t.TempDir(), "cache.json")
+	cache, err := LoadWitCache(cacheFilePath)
+	if err != nil || len(cache.WorkItems) != 0 {
+		t.Fatalf("LoadWitCache() of a missing file = %v, %v", cache, err)
+	}
+
+	cache.Update([]workitemtracking.WorkItem{newTestWorkItem(3, 1), newTestWorkItem(1, 4), newTestWorkItem(2, 1)})
+	cache.Update([]workitemtracking.WorkItem{newTestWorkItem(1, 2), newTestWorkItem(2, 5)})
+	if got := *cache.WorkItems["1"].Rev; got != 4 {
+		t.Errorf("Update() replaced a newer revision, Rev = %d", got)
+	}
+	if got := *cache.WorkItems["2"].Rev; got != 5 {
+		t.Errorf("Update() kept an older revision, Rev = %d", got)
+	}
+
+	cache.Retain([]int{1, 2, 4})
+	if got := cache.Missing([]int{1, 2, 3, 4}); !slices.Equal(got, []int{3, 4}) {
+		t.Errorf("Missing() = %v, want [3 4]", got)
+	}
+
+	err = cache.Save(cacheFilePath)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := LoadWitCache(cacheFilePath)
+	if err != nil {
+		t.Fatalf("LoadWitCache() error = %v", err)
+	}
+	ids := []int{}
+	for _, wit := range loaded.List() {
+		ids = append(ids, *wit.Id)
+	}
+	if !slices.Equal(ids, []int{1, 2}) {
+		t.Errorf("List() ids = %v, want [1 2]", ids)
+	}
+}
@@ -15,16 +15,48 @@ import (
 func main() {
 	action := flag.String("action", "none", "The action to take")
 	configFilePath := flag.String("cfg", "none", "Configuration file path")
+	plan := flag.Bool("plan", false, "Print what the daily submit would change, without submitting")
+	phase := flag.String("phase", "", "Phase to reset today's daily directory to: empty, downloaded or editing")
 	flag.Parse()
 
-	if *action == "daily" {
-		human_api.DailyRoutine(*configFilePath)
+	if *action == "daily" && *plan {
+		dailyPlan, err := human_api.DailyRoutinePlan(*configFilePath)
+		if err != nil {
+			log.Fatalf("Error received '%v'", err)
+		}
+		fmt.Print(dailyPlan)
+		return
+	} else if *action == "daily" {
+		err := human_api.DailyRoutine(*configFilePath)
+		if err != nil {
+			log.Fatalf("Error received '%v'", err)
+		}
+	} else if *action == "status" {
+		status, err := human_api.DailyRoutineStatus(*configFilePath)
+		if err != nil {
+			log.Fatalf("Error received '%v'", err)
+		}
+		fmt.Print(status)
+		return
+	} else if *action == "reset" {
+		err := human_api.DailyRoutineReset(*configFilePath, *phase)
+		if err != nil {
+			log.Fatalf("Error received '%v'", err)
+		}
+	} else if *action == "redownload" {
+		err := human_api.DailyRoutineRedownload(*configFilePath)
+		if err != nil {
+			log.Fatalf("Error received '%v'", err)
+		}
 	} else if *action == "download_all" {
 		config, err := azure_devops_api.LoadConfig(*configFilePath)
 		if err != nil {
 			log.Fatalf("Error received '%v'", err)
 		}
-		azure_devops_api.DownloadAllWits(config, "/tmp/wit.json")
+		err = azure_devops_api.DownloadAllWits(config, "/tmp/wit.json")
+		if err != nil {
+			log.Fatalf("Error received '%v'", err)
+		}
 	} else {
 		log.Fatalf("Unknown action '%v'", *action)
 	}
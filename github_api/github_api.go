@@ -0,0 +1,400 @@
+package github_api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultBaseURL = "https://api.github.com"
+
+// Configuration of a single repository. Milestones are used as sprints.
+type Configuration struct {
+	BaseURL       string   `json:"BaseURL"`
+	Token         string   `json:"Token"`
+	Owner         string   `json:"Owner"`
+	Repo          string   `json:"Repo"`
+	SprintName    string   `json:"SprintName"`
+	ActiveLabel   string   `json:"ActiveLabel"`
+	BlockedLabel  string   `json:"BlockedLabel"`
+	DefaultLabels []string `json:"DefaultLabels"`
+}
+
+type Milestone struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+}
+
+type User struct {
+	Login string `json:"login"`
+}
+
+type Label struct {
+	Name string `json:"name"`
+}
+
+type IssueType struct {
+	Name string `json:"name"`
+}
+
+// Issue as returned by the issues API. ParentNumber is filled by DownloadMilestoneIssues.
+type Issue struct {
+	ID           int64      `json:"id"`
+	Number       int        `json:"number"`
+	Title        string     `json:"title"`
+	Body         string     `json:"body"`
+	State        string     `json:"state"`
+	User         *User      `json:"user"`
+	Assignees    []User     `json:"assignees"`
+	Labels       []Label    `json:"labels"`
+	Milestone    *Milestone `json:"milestone"`
+	Type         *IssueType `json:"type"`
+	PullRequest  any        `json:"pull_request,omitempty"`
+	ParentNumber int        `json:"parent_number,omitempty"`
+}
+
+// Snapshot is the JSON file DownloadMilestoneIssues writes.
+// Parents holds parents of milestone issues which are not part of the milestone.
+type Snapshot struct {
+	Milestone Milestone `json:"milestone"`
+	Issues    []Issue   `json:"issues"`
+	Parents   []Issue   `json:"parents"`
+}
+
+func LoadConfig(configFilePath string) (config Configuration, err error) {
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+func ValidateConfig(config Configuration) error {
+	if config.Owner == "" || config.Repo == "" {
+		return fmt.Errorf("parameters Owner and Repo must be set in config")
+	}
+	return nil
+}
+
+func getClient() http.Client {
+	return http.Client{Timeout: 10 * time.Second}
+}
+
+func createRequest(config Configuration, ctx context.Context, RequestPath string, httpMethod string, body io.Reader) (*http.Request, error) {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	requestUrl := strings.TrimRight(baseURL, "/") + "/repos/" + config.Owner + "/" + config.Repo + "/" + RequestPath
+
+	req, err := http.NewRequestWithContext(ctx, httpMethod, requestUrl, body)
+	if err != nil {
+		return req, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+config.Token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// Send the request and decode the JSON response into ret, unless ret is nil.
+// Returns the HTTP status code, so callers can handle 404 themselves.
+func call(config Configuration, httpMethod string, RequestPath string, body interface{}, ret interface{}) (int, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		postData, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("error marshaling JSON: %v", err)
+		}
+		bodyReader = bytes.NewBuffer(postData)
+	}
+
+	req, err := createRequest(config, context.Background(), RequestPath, httpMethod, bodyReader)
+	if err != nil {
+		return 0, err
+	}
+	client := getClient()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("received error in HTTP clinet request: %v", err)
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respData, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, fmt.Errorf("HTTP status error: %s %s: %d %s %s", httpMethod, RequestPath, resp.StatusCode, resp.Status, string(respData))
+	}
+
+	if ret == nil || resp.StatusCode == http.StatusNoContent {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(ret)
+}
+
+func GetMilestone(config Configuration) (milestone Milestone, err error) {
+	for page := 1; ; page++ {
+		var milestones []Milestone
+		_, err = call(config, http.MethodGet, fmt.Sprintf("milestones?state=all&per_page=100&page=%d", page), nil, &milestones)
+		if err != nil {
+			return milestone, err
+		}
+		for _, value := range milestones {
+			if value.Title == config.SprintName {
+				return value, nil
+			}
+		}
+		if len(milestones) < 100 {
+			break
+		}
+	}
+	return milestone, fmt.Errorf("was not able to find Milestone by title: %s", config.SprintName)
+}
+
+// Issues of the milestone, pull requests excluded.
+func GetMilestoneIssues(config Configuration, milestone Milestone) (issues []Issue, err error) {
+	for page := 1; ; page++ {
+		var pageIssues []Issue
+		_, err = call(config, http.MethodGet, fmt.Sprintf("issues?milestone=%d&state=all&per_page=100&page=%d", milestone.Number, page), nil, &pageIssues)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range pageIssues {
+			if issue.PullRequest != nil {
+				continue
+			}
+			issues = append(issues, issue)
+		}
+		if len(pageIssues) < 100 {
+			break
+		}
+	}
+	return issues, nil
+}
+
+func GetIssue(config Configuration, number int) (issue Issue, err error) {
+	_, err = call(config, http.MethodGet, fmt.Sprintf("issues/%d", number), nil, &issue)
+	return issue, err
+}
+
+// Return the parent issue of a sub-issue, ok is false if the issue has no parent.
+func GetParentIssue(config Configuration, number int) (parent Issue, ok bool, err error) {
+	statusCode, err := call(config, http.MethodGet, fmt.Sprintf("issues/%d/parent", number), nil, &parent)
+	if statusCode == http.StatusNotFound {
+		return parent, false, nil
+	}
+	if err != nil {
+		return parent, false, err
+	}
+	return parent, true, nil
+}
+
+func DownloadMilestoneIssues(config Configuration, dstFilePath string) error {
+	err := ValidateConfig(config)
+	if err != nil {
+		return err
+	}
+
+	milestone, err := GetMilestone(config)
+	if err != nil {
+		return err
+	}
+
+	issues, err := GetMilestoneIssues(config, milestone)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("fetched %d issues of milestone %s\n", len(issues), milestone.Title)
+
+	snapshot := Snapshot{Milestone: milestone, Issues: []Issue{}, Parents: []Issue{}}
+	known := make(map[int]bool)
+	for _, issue := range issues {
+		known[issue.Number] = true
+	}
+	for _, issue := range issues {
+		parent, ok, err := GetParentIssue(config, issue.Number)
+		if err != nil {
+			return err
+		}
+		if ok {
+			issue.ParentNumber = parent.Number
+			if !known[parent.Number] {
+				known[parent.Number] = true
+				snapshot.Parents = append(snapshot.Parents, parent)
+			}
+		}
+		snapshot.Issues = append(snapshot.Issues, issue)
+	}
+
+	return CacheToFile(snapshot, dstFilePath)
+}
+
+// Download the issues with the given numbers, parents are referenced by number only.
+func DownloadIssues(config Configuration, numbers []int, dstFilePath string) error {
+	snapshot := Snapshot{Issues: []Issue{}, Parents: []Issue{}}
+	for _, number := range numbers {
+		issue, err := GetIssue(config, number)
+		if err != nil {
+			return err
+		}
+		parent, ok, err := GetParentIssue(config, number)
+		if err != nil {
+			return err
+		}
+		if ok {
+			issue.ParentNumber = parent.Number
+		}
+		snapshot.Issues = append(snapshot.Issues, issue)
+	}
+
+	return CacheToFile(snapshot, dstFilePath)
+}
+
+func CacheToFile(snapshot Snapshot, dstFilePath string) (err error) {
+	jsonData, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(dstFilePath, jsonData, 0644)
+}
+
+func ReadSnapshotFromFile(filePath string) (snapshot Snapshot, err error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return snapshot, err
+	}
+
+	err = json.Unmarshal(data, &snapshot)
+	return snapshot, err
+}
+
+// Create an issue in the configured milestone and return it.
+func CreateIssue(config Configuration, title, body string, assignees []string) (issue Issue, err error) {
+	milestone, err := GetMilestone(config)
+	if err != nil {
+		return issue, err
+	}
+
+	request := map[string]interface{}{
+		"title":     title,
+		"body":      body,
+		"assignees": assignees,
+		"milestone": milestone.Number,
+	}
+	if len(config.DefaultLabels) > 0 {
+		request["labels"] = config.DefaultLabels
+	}
+
+	_, err = call(config, http.MethodPost, "issues", request, &issue)
+	if err != nil {
+		return issue, err
+	}
+	fmt.Printf("Created new GitHub issue: #%d\n", issue.Number)
+	return issue, nil
+}
+
+func UpdateIssue(config Configuration, number int, fields map[string]interface{}) error {
+	fmt.Printf("Updating GitHub issue: #%d %v\n", number, fields)
+	_, err := call(config, http.MethodPatch, fmt.Sprintf("issues/%d", number), fields, nil)
+	return err
+}
+
+// Open or close the issue and keep exactly one of the status labels.
+// Status is a hapi status: New, Active, Blocked or Closed.
+func SetIssueStatus(config Configuration, number int, status string) error {
+	issue, err := GetIssue(config, number)
+	if err != nil {
+		return err
+	}
+
+	labels := []string{}
+	for _, label := range issue.Labels {
+		if label.Name == getActiveLabel(config) || label.Name == getBlockedLabel(config) {
+			continue
+		}
+		labels = append(labels, label.Name)
+	}
+
+	fields := map[string]interface{}{"state": "open"}
+	switch status {
+	case "Closed":
+		fields["state"] = "closed"
+		fields["state_reason"] = "completed"
+	case "Active":
+		labels = append(labels, getActiveLabel(config))
+	case "Blocked":
+		labels = append(labels, getBlockedLabel(config))
+	case "New":
+	default:
+		return fmt.Errorf("unknown status '%s' for issue #%d", status, number)
+	}
+	fields["labels"] = labels
+
+	return UpdateIssue(config, number, fields)
+}
+
+// Make the issue a sub-issue of parentNumber, replacing its current parent.
+func SetIssueParent(config Configuration, number, parentNumber int) error {
+	issue, err := GetIssue(config, number)
+	if err != nil {
+		return err
+	}
+
+	request := map[string]interface{}{"sub_issue_id": issue.ID, "replace_parent": true}
+	_, err = call(config, http.MethodPost, fmt.Sprintf("issues/%d/sub_issues", parentNumber), request, nil)
+	return err
+}
+
+func AddComment(config Configuration, number int, body string) error {
+	_, err := call(config, http.MethodPost, fmt.Sprintf("issues/%d/comments", number), map[string]string{"body": body}, nil)
+	return err
+}
+
+// Return the hapi status of the issue.
+func GetIssueStatus(config Configuration, issue Issue) string {
+	if issue.State == "closed" {
+		return "Closed"
+	}
+	for _, label := range issue.Labels {
+		if label.Name == getBlockedLabel(config) {
+			return "Blocked"
+		}
+	}
+	for _, label := range issue.Labels {
+		if label.Name == getActiveLabel(config) {
+			return "Active"
+		}
+	}
+	return "New"
+}
+
+func getActiveLabel(config Configuration) string {
+	if config.ActiveLabel == "" {
+		return "in progress"
+	}
+	return config.ActiveLabel
+}
+
+func getBlockedLabel(config Configuration) string {
+	if config.BlockedLabel == "" {
+		return "blocked"
+	}
+	return config.BlockedLabel
+}
@@ -0,0 +1,154 @@
+package github_api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Fake of the GitHub REST API for a single repository, recording the mutating calls it receives.
+type fakeGithub struct {
+	issues  map[string]Issue
+	parents map[string]Issue
+	calls   []string
+}
+
+func newFakeGithub() *fakeGithub {
+	milestone := &Milestone{Number: 3, Title: "Sprint 2"}
+	story := Issue{ID: 901, Number: 1, Title: "story", State: "open", User: &User{Login: "horey"}}
+	return &fakeGithub{
+		issues: map[string]Issue{
+			"1": story,
+			"2": {ID: 902, Number: 2, Title: "task 2", State: "open", Milestone: milestone, Assignees: []User{{Login: "horey"}}, Labels: []Label{{Name: "in progress"}, {Name: "backend"}}},
+			"3": {ID: 903, Number: 3, Title: "task 3", State: "closed", Milestone: milestone, Assignees: []User{{Login: "horey"}}},
+		},
+		parents: map[string]Issue{"2": story},
+	}
+}
+
+func (fake *fakeGithub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/repos/horey/hapi/")
+	write := func(value interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(value)
+	}
+	record := func() {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		data, _ := json.Marshal(body)
+		fake.calls = append(fake.calls, r.Method+" "+path+" "+string(data))
+	}
+
+	parts := strings.Split(path, "/")
+	switch {
+	case path == "milestones":
+		write([]Milestone{{Number: 2, Title: "Sprint 1"}, {Number: 3, Title: "Sprint 2"}})
+	case path == "issues" && r.Method == http.MethodGet:
+		pullRequest := Issue{ID: 904, Number: 4, Title: "pull request", State: "open", PullRequest: map[string]string{"url": "pull/4"}}
+		write([]Issue{fake.issues["2"], fake.issues["3"], pullRequest})
+	case path == "issues" && r.Method == http.MethodPost:
+		record()
+		w.WriteHeader(http.StatusCreated)
+		write(Issue{ID: 905, Number: 5})
+	case len(parts) == 3 && parts[2] == "parent":
+		parent, ok := fake.parents[parts[1]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		write(parent)
+	case len(parts) == 3 && (parts[2] == "sub_issues" || parts[2] == "comments"):
+		record()
+		w.WriteHeader(http.StatusCreated)
+		write(map[string]string{})
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		issue, ok := fake.issues[parts[1]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		write(issue)
+	case len(parts) == 2 && r.Method == http.MethodPatch:
+		record()
+		write(fake.issues[parts[1]])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestConfig(server *httptest.Server) Configuration {
+	return Configuration{BaseURL: server.URL, Token: "token", Owner: "horey", Repo: "hapi", SprintName: "Sprint 2"}
+}
+
+func TestDownloadMilestoneIssues(t *testing.T) {
+	t.Run("Milestone with parent outside of it", func(t *testing.T) {
+		server := httptest.NewServer(newFakeGithub())
+		defer server.Close()
+		config := newTestConfig(server)
+
+		dstFilePath := filepath.Join(t.TempDir(), "pre_report.json")
+		err := DownloadMilestoneIssues(config, dstFilePath)
+		if err != nil {
+			t.Fatalf("DownloadMilestoneIssues() error = %v", err)
+		}
+
+		snapshot, err := ReadSnapshotFromFile(dstFilePath)
+		if err != nil {
+			t.Fatalf("ReadSnapshotFromFile() error = %v", err)
+		}
+		if snapshot.Milestone.Number != 3 || len(snapshot.Issues) != 2 || len(snapshot.Parents) != 1 {
+			t.Fatalf("DownloadMilestoneIssues() snapshot = %+v", snapshot)
+		}
+		if snapshot.Issues[0].ParentNumber != 1 || snapshot.Issues[1].ParentNumber != 0 {
+			t.Errorf("DownloadMilestoneIssues() parent numbers = %d, %d", snapshot.Issues[0].ParentNumber, snapshot.Issues[1].ParentNumber)
+		}
+		if GetIssueStatus(config, snapshot.Issues[0]) != "Active" || GetIssueStatus(config, snapshot.Issues[1]) != "Closed" {
+			t.Errorf("GetIssueStatus() = %s, %s", GetIssueStatus(config, snapshot.Issues[0]), GetIssueStatus(config, snapshot.Issues[1]))
+		}
+	})
+}
+
+func TestSubmitCalls(t *testing.T) {
+	t.Run("Open, close, re-parent and comment", func(t *testing.T) {
+		fake := newFakeGithub()
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		config := newTestConfig(server)
+
+		issue, err := CreateIssue(config, "new task", "body", []string{"horey"})
+		if err != nil || issue.Number != 5 {
+			t.Fatalf("CreateIssue() = %v, %v", issue, err)
+		}
+		err = SetIssueStatus(config, 2, "Closed")
+		if err != nil {
+			t.Fatalf("SetIssueStatus() error = %v", err)
+		}
+		err = SetIssueStatus(config, 3, "Blocked")
+		if err != nil {
+			t.Fatalf("SetIssueStatus() error = %v", err)
+		}
+		err = SetIssueParent(config, 3, 1)
+		if err != nil {
+			t.Fatalf("SetIssueParent() error = %v", err)
+		}
+		err = AddComment(config, 3, "waiting for review")
+		if err != nil {
+			t.Fatalf("AddComment() error = %v", err)
+		}
+
+		want := []string{
+			`POST issues {"assignees":["horey"],"body":"body","milestone":3,"title":"new task"}`,
+			`PATCH issues/2 {"labels":["backend"],"state":"closed","state_reason":"completed"}`,
+			`PATCH issues/3 {"labels":["blocked"],"state":"open"}`,
+			`POST issues/1/sub_issues {"replace_parent":true,"sub_issue_id":903}`,
+			`POST issues/3/comments {"body":"waiting for review"}`,
+		}
+		if !reflect.DeepEqual(fake.calls, want) {
+			t.Errorf("calls = %v, want %v", fake.calls, want)
+		}
+	})
+}
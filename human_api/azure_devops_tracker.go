@@ -0,0 +1,398 @@
+package human_api
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexeyBeley/human_api/azure_devops_api"
+)
+
+// Tracker implementation backed by azure_devops_api.
+// States maps the work item states of the process, DefaultStateMappings when empty.
+// Types names the work item types, DefaultWobjectTypes when empty.
+type AzureDevopsTracker struct {
+	Config azure_devops_api.Configuration
+	States StateMappings
+	Types  WobjectTypes
+}
+
+func NewAzureDevopsTracker(config azure_devops_api.Configuration, states StateMappings, types WobjectTypes) *AzureDevopsTracker {
+	return &AzureDevopsTracker{Config: config, States: states, Types: types}
+}
+
+func (tracker *AzureDevopsTracker) states() StateMappings {
+	if len(tracker.States) == 0 {
+		return DefaultStateMappings()
+	}
+	return tracker.States
+}
+
+func (tracker *AzureDevopsTracker) types() WobjectTypes {
+	if len(tracker.Types) == 0 {
+		return DefaultWobjectTypes()
+	}
+	return tracker.Types
+}
+
+func (tracker *AzureDevopsTracker) Download(dstFilePath string) error {
+	if tracker.Config.CacheFilePath != "" {
+		log.Printf("downloadChangedWits: %v\n", dstFilePath)
+		return azure_devops_api.DownloadChangedWits(tracker.Config, tracker.Config.CacheFilePath, dstFilePath)
+	}
+	log.Printf("downloadAllWits: %v\n", dstFilePath)
+	return azure_devops_api.DownloadAllWits(tracker.Config, dstFilePath)
+}
+
+func (tracker *AzureDevopsTracker) DownloadWobjects(wobjectIds []string, dstFilePath string) error {
+	witIds := []int{}
+	for _, wobjectId := range wobjectIds {
+		witId, err := strconv.Atoi(wobjectId)
+		if err != nil {
+			return fmt.Errorf("azure devops work item id '%s': %v", wobjectId, err)
+		}
+		witIds = append(witIds, witId)
+	}
+	return azure_devops_api.DownloadWits(tracker.Config, witIds, dstFilePath)
+}
+
+func (tracker *AzureDevopsTracker) ReadWobjects(srcFilePath string) (map[string]*Wobject, error) {
+	return ConvertAzureDevopsStatusToWobjects(srcFilePath, tracker.states())
+}
+
+func (tracker *AzureDevopsTracker) CreateWobject(wobject *Wobject) error {
+	requestDict, err := tracker.generateRequestDict(wobject)
+	if err != nil {
+		return err
+	}
+	err = azure_devops_api.CreateWit(tracker.Config, &requestDict)
+	if err != nil {
+		return transitionError(wobject, requestDict, err)
+	}
+	wobject.Id = requestDict["Id"]
+	return nil
+}
+
+func (tracker *AzureDevopsTracker) UpdateWobject(wobject *Wobject) error {
+	requestDict, err := tracker.generateRequestDict(wobject)
+	if err != nil {
+		return err
+	}
+	err = azure_devops_api.UpdateWit(tracker.Config, requestDict)
+	if err != nil {
+		return transitionError(wobject, requestDict, err)
+	}
+	return nil
+}
+
+func (tracker *AzureDevopsTracker) SetWobjectParent(wobject *Wobject) error {
+	requestDict, err := tracker.generateRequestDict(wobject)
+	if err != nil {
+		return err
+	}
+	return azure_devops_api.SetWitParent(tracker.Config, &requestDict)
+}
+
+// Azure DevOps expects the assignee display name rather than the hapi worker id.
+func (tracker *AzureDevopsTracker) generateRequestDict(wobject *Wobject) (map[string]string, error) {
+	if !strings.HasPrefix(wobject.Id, "CreatePlease:") {
+		if _, err := strconv.Atoi(wobject.Id); err != nil {
+			return nil, newValidationError("wobject [%s] [%s] Id: %v", wobject.Id, wobject.Title, err)
+		}
+	}
+	// An empty ParentID is a wobject without a parent, like -1.
+	if wobject.ParentID != "" && !strings.HasPrefix(wobject.ParentID, "CreatePlease:") {
+		if _, err := strconv.Atoi(wobject.ParentID); err != nil {
+			return nil, newValidationError("wobject [%s] [%s] ParentID: %v", wobject.Id, wobject.Title, err)
+		}
+	}
+
+	requestDict := GenerateDictFromWobject(wobject)
+	var err error
+	if requestDict["WorkerID"] != "" {
+		requestDict["WorkerID"], err = azure_devops_api.GetWorker(requestDict["WorkerID"])
+		if err != nil {
+			return nil, newValidationError("[%s][%s] - %v", wobject.Id, wobject.Title, err)
+		}
+	}
+	if wobject.Status != "" {
+		requestDict["State"], err = tracker.submitState(wobject)
+		if err != nil {
+			return nil, newValidationError("[%s][%s] - %v", wobject.Id, wobject.Title, err)
+		}
+		// A wobject in a Blocked state is not tagged.
+		blockedTag := tracker.states().BlockedTag(wobject.Type)
+		if stateStatus, _ := tracker.states().Status(wobject.Type, requestDict["State"]); stateStatus == "Blocked" {
+			blockedTag = ""
+		}
+		if blocked := wobject.Status == "Blocked"; blockedTag != "" && hasTag(wobject.Tags, blockedTag) != blocked {
+			requestDict[azure_devops_api.FieldKeyPrefix+"System.Tags"] = setTag(wobject.Tags, blockedTag, blocked)
+		}
+	}
+	// Fields the process requires are only sent with the transition, not with every update.
+	if requestDict["State"] != "" && requestDict["State"] != wobject.State {
+		for field, value := range tracker.states().TransitionFields(wobject.Type, requestDict["State"]) {
+			requestDict[azure_devops_api.FieldKeyPrefix+field] = strings.ReplaceAll(value, transitionWorkerPlaceholder, requestDict["WorkerID"])
+		}
+	}
+	if wobject.Description != "" && wobject.Description != "-1" {
+		reportDate := wobject.ReportDate
+		if reportDate.IsZero() {
+			reportDate = time.Now()
+		}
+		requestDict["History"] = formatDiscussionEntry(reportDate, wobject.Description)
+	}
+	if wobjectType, ok := tracker.types().Get(wobject.Type); ok {
+		requestDict["TypeUrlName"] = wobjectType.UrlName
+	}
+	return requestDict, nil
+}
+
+// The daily comment is posted as a discussion entry, prefixed with the day it
+// was reported so the entries read as a timeline. History fields are HTML.
+func formatDiscussionEntry(date time.Time, comment string) string {
+	text := strings.ReplaceAll(html.EscapeString(comment), "\n", "<br>")
+	return fmt.Sprintf("<b>%s</b>: %s", date.Format("2006-01-02"), text)
+}
+
+// State to submit the wobject status as. The state the wobject was read in is
+// kept while it maps to the status, so a resolved item is not closed by an update.
+// Blocked wobjects marked by a tag keep their Active state.
+func (tracker *AzureDevopsTracker) submitState(wobject *Wobject) (string, error) {
+	stateStatus := wobject.Status
+	if stateStatus == "Blocked" && tracker.states().BlockedTag(wobject.Type) != "" {
+		stateStatus = "Active"
+	}
+	if wobject.State != "" {
+		status, err := tracker.states().Status(wobject.Type, wobject.State)
+		if err == nil && (status == wobject.Status || status == stateStatus) {
+			return wobject.State, nil
+		}
+	}
+	return tracker.states().State(wobject.Type, wobject.Status)
+}
+
+// Azure DevOps tags are separated by "; " and compared case-insensitively.
+func hasTag(tags string, tag string) bool {
+	return slices.ContainsFunc(strings.Split(tags, ";"), func(item string) bool { return strings.EqualFold(strings.TrimSpace(item), tag) })
+}
+
+// The tags with the tag added or removed. System.Tags is replaced by the patch.
+func setTag(tags string, tag string, set bool) string {
+	items := []string{}
+	for _, item := range strings.Split(tags, ";") {
+		item = strings.TrimSpace(item)
+		if item != "" && !strings.EqualFold(item, tag) {
+			items = append(items, item)
+		}
+	}
+	if set {
+		items = append(items, tag)
+	}
+	return strings.Join(items, "; ")
+}
+
+// Rule errors of a request changing the state are reported as a rejected
+// transition, the process either forbids it or requires more fields.
+func transitionError(wobject *Wobject, requestDict map[string]string, err error) error {
+	var responseError *azure_devops_api.ResponseError
+	if requestDict["State"] == "" || requestDict["State"] == wobject.State || !errors.As(err, &responseError) || !responseError.IsRuleError() {
+		return err
+	}
+	reasons := []string{}
+	for _, ruleError := range responseError.RuleErrors {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", ruleError.FieldReferenceName, ruleError.ErrorMessage))
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, responseError.Message)
+	}
+	return &TransitionError{WobjectId: wobject.Id, Type: wobject.Type, From: wobject.State, To: requestDict["State"], Reasons: reasons, Err: err}
+}
+
+// Backlog levels of the process template, top down. Every level is the parent of the next one.
+var azureDevopsBacklogCategories = []string{"Microsoft.EpicCategory", "Microsoft.FeatureCategory", "Microsoft.RequirementCategory", "Microsoft.TaskCategory"}
+
+// Read the types from the backlog levels of the process template. Task and bug
+// category types are leaves, bugs are children of requirements like tasks are.
+// Types not on a backlog level are not included.
+func (tracker *AzureDevopsTracker) DiscoverWobjectTypes() (WobjectTypes, error) {
+	categories, err := azure_devops_api.GetWorkItemTypeCategories(tracker.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	types := WobjectTypes{}
+	addLevel := func(urlNames []string, leaf bool, parentUrlNames []string) {
+		parents := []string{}
+		for _, parentUrlName := range parentUrlNames {
+			parents = append(parents, strings.ReplaceAll(parentUrlName, " ", ""))
+		}
+		for _, urlName := range urlNames {
+			name := strings.ReplaceAll(urlName, " ", "")
+			if _, ok := types.Get(name); ok {
+				continue
+			}
+			types = append(types, WobjectType{Name: name, UrlName: urlName, Leaf: leaf, Parents: parents})
+		}
+	}
+
+	parentUrlNames := []string{}
+	for _, category := range azureDevopsBacklogCategories {
+		addLevel(categories[category], category == "Microsoft.TaskCategory", parentUrlNames)
+		parentUrlNames = categories[category]
+	}
+	addLevel(categories["Microsoft.BugCategory"], true, categories["Microsoft.RequirementCategory"])
+
+	if len(types) == 0 {
+		return nil, fmt.Errorf("no backlog work item types found in project '%s'", tracker.Config.ProjectName)
+	}
+	tracker.Types = types
+	return types, nil
+}
+
+// Work items without a state mapping are left out and listed by the returned
+// UnmappedStatesError, the other work items are returned with it.
+func ConvertAzureDevopsStatusToWobjects(filePath string, states StateMappings) (wobjects map[string]*Wobject, err error) {
+	wits, err := azure_devops_api.ReadWitsFromFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	wobjects = make(map[string]*Wobject)
+
+	unmapped := &UnmappedStatesError{}
+	for _, wit := range wits {
+		wobject, err := ConvertWitToWobject(wit, states)
+		if errors.Is(err, errUnmappedState) {
+			unmapped.Errors = append(unmapped.Errors, err.Error())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		wobjects[wobject.Id] = &wobject
+	}
+	for wobjId, wobject := range wobjects {
+		if wobject.ParentID != "" && wobject.ParentID != "-1" {
+			parent, ok := wobjects[wobject.ParentID]
+			if !ok {
+				continue
+			}
+			*(parent.ChildrenIDs) = append(*(parent.ChildrenIDs), wobjId)
+		}
+	}
+	if len(unmapped.Errors) > 0 {
+		return wobjects, unmapped
+	}
+	return wobjects, nil
+}
+
+func ConvertWitToWobject(wit azure_devops_api.WorkItem, states StateMappings) (wobject Wobject, err error) {
+	wobject.Id = strconv.Itoa(wit.ID)
+	wobject.Rev = wit.Rev
+	wobject.ParentID, err = extractFloat64String(wit, "System.Parent")
+	if err != nil {
+		return wobject, err
+	}
+	wobject.Title, err = extractString(wit, "System.Title")
+	if err != nil {
+		return wobject, err
+	}
+	wobject.Priority, err = extractFloat64Int(wit, "Microsoft.VSTS.Common.Priority")
+	if err != nil {
+		return wobject, err
+	}
+	wobject.LeftTime, err = extractFloat64Int(wit, "Microsoft.VSTS.Scheduling.RemainingWork")
+	if err != nil {
+		return wobject, err
+	}
+
+	wobject.WorkerID, err = extractWorkerID(wit)
+	if err != nil {
+		return wobject, err
+	}
+	wobject.ChildrenIDs = &[]string{}
+
+	iterationPath, err := extractString(wit, "System.IterationPath")
+	if err != nil {
+		return wobject, err
+	}
+	SprintParts := strings.Split(iterationPath, "\\")
+	wobject.Sprint = SprintParts[len(SprintParts)-1]
+	workItemType, err := extractString(wit, "System.WorkItemType")
+	if err != nil {
+		return wobject, err
+	}
+	wobject.Type = strings.Replace(workItemType, " ", "", -1)
+
+	state, err := extractString(wit, "System.State")
+	if err != nil {
+		return wobject, err
+	}
+	wobject.State = state
+	wobject.Status, err = states.Status(wobject.Type, state)
+	if err != nil {
+		return wobject, fmt.Errorf("work item %d: %w", wit.ID, err)
+	}
+	// Tags are not set on work items without them.
+	wobject.Tags, _ = wit.Fields["System.Tags"].(string)
+	if blockedTag := states.BlockedTag(wobject.Type); wobject.Status == "Active" && blockedTag != "" && hasTag(wobject.Tags, blockedTag) {
+		wobject.Status = "Blocked"
+	}
+	return wobject, nil
+}
+
+func extractWorkerID(workItem azure_devops_api.WorkItem) (string, error) {
+	FieldKey := "System.AssignedTo"
+	if workItem.Fields[FieldKey] == nil {
+		FieldKey = "System.CreatedBy"
+	}
+
+	identity, ok := workItem.Fields[FieldKey].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("work item %d: field %s is not an identity: %v", workItem.ID, FieldKey, workItem.Fields[FieldKey])
+	}
+	data, ok := identity["uniqueName"].(string)
+	if !ok {
+		return "", fmt.Errorf("work item %d: field %s has no uniqueName: %v", workItem.ID, FieldKey, identity)
+	}
+
+	return strings.Split(data, "@")[0], nil
+}
+
+func extractString(workItem azure_devops_api.WorkItem, FieldKey string) (string, error) {
+	value, ok := workItem.Fields[FieldKey].(string)
+	if !ok {
+		return "", fmt.Errorf("work item %d: was not able to extract string field %s: %v", workItem.ID, FieldKey, workItem.Fields[FieldKey])
+	}
+	return value, nil
+}
+
+func extractFloat64Int(workItem azure_devops_api.WorkItem, FieldKey string) (int, error) {
+	var retVal int
+	if workItem.Fields[FieldKey] == nil {
+		return retVal, nil
+	}
+
+	value, ok := workItem.Fields[FieldKey].(float64)
+	if !ok {
+		return retVal, fmt.Errorf("extractFloat64Int: Was not able to Extract %v, %v, %v", FieldKey, workItem.Fields[FieldKey], workItem.ID)
+	}
+	return strconv.Atoi(strconv.FormatFloat(value, 'f', 0, 64))
+}
+
+func extractFloat64String(workItem azure_devops_api.WorkItem, FieldKey string) (string, error) {
+	if workItem.Fields[FieldKey] == nil {
+		return "", nil
+	}
+
+	retVal, err := extractFloat64Int(workItem, FieldKey)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(retVal), nil
+}
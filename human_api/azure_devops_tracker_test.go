@@ -0,0 +1,104 @@
+package human_api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatDiscussionEntry(t *testing.T) {
+	date := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)
+	got := formatDiscussionEntry(date, "waiting for <review>\nretry tomorrow")
+	want := "<b>2024-03-05</b>: waiting for &lt;review&gt;<br>retry tomorrow"
+	if got != want {
+		t.Errorf("formatDiscussionEntry() = %q, want %q", got, want)
+	}
+
+	tracker := &AzureDevopsTracker{}
+	wobject, err := ConvertWitToWobject(newTestWit(16, "Task", "Active"), tracker.states())
+	if err != nil {
+		t.Fatalf("ConvertWitToWobject() error = %v", err)
+	}
+	wobject.ParentID = "-1"
+	wobject.WorkerID = ""
+	for _, description := range []string{"", "-1"} {
+		wobject.Description = description
+		requestDict, err := tracker.generateRequestDict(&wobject)
+		if err != nil || requestDict["History"] != "" {
+			t.Errorf("generateRequestDict() History of comment '%s' = %q, %v", description, requestDict["History"], err)
+		}
+	}
+	wobject.Description = "deployed to staging"
+	requestDict, err := tracker.generateRequestDict(&wobject)
+	if err != nil || !strings.HasSuffix(requestDict["History"], "</b>: deployed to staging") || !strings.Contains(requestDict["History"], time.Now().Format("2006-01-02")) {
+		t.Errorf("generateRequestDict() History = %q, %v", requestDict["History"], err)
+	}
+}
+
+// Tracker recording the History Azure DevOps would be sent by every update.
+type historyTracker struct {
+	recordingTracker
+	histories []string
+}
+
+func (tracker *historyTracker) UpdateWobject(wobject *Wobject) error {
+	requestDict, err := (&AzureDevopsTracker{}).generateRequestDict(wobject)
+	if err != nil {
+		return err
+	}
+	tracker.histories = append(tracker.histories, requestDict["History"])
+	return nil
+}
+
+func TestSubmitDiscussionEntry(t *testing.T) {
+	reportDate := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	base := &Wobject{Id: "2", Type: "Task", Title: "task", Description: "waiting for review", LeftTime: 5, InvestedTime: -1, ParentID: "-1", Priority: -1, ChildrenIDs: &[]string{}}
+	tracker := &historyTracker{}
+	for _, description := range []string{"waiting for review", "merged"} {
+		input := *base
+		input.LeftTime = 3
+		input.Description = description
+		input.ReportDate = reportDate
+		plan, err := GeneratePlan(map[string]*Wobject{"2": base}, []*Wobject{&input})
+		if err != nil {
+			t.Fatalf("GeneratePlan() error = %v", err)
+		}
+		journal, err := LoadJournal("")
+		if err != nil {
+			t.Fatalf("LoadJournal() error = %v", err)
+		}
+		err = ApplyPlan(tracker, plan, journal)
+		if err != nil {
+			t.Fatalf("ApplyPlan() error = %v", err)
+		}
+	}
+
+	want := []string{"", "<b>2024-03-05</b>: merged"}
+	if len(tracker.histories) != 2 || tracker.histories[0] != want[0] || tracker.histories[1] != want[1] {
+		t.Errorf("ApplyPlan() History = %q, want the changed comment only %q", tracker.histories, want)
+	}
+}
+
+func TestGenerateRequestDictIds(t *testing.T) {
+	tracker := &AzureDevopsTracker{}
+	for _, wobject := range []Wobject{
+		{Id: "12", Type: "Task", ChildrenIDs: &[]string{}},
+		{Id: "12", Type: "Task", ParentID: "CreatePlease:story", ChildrenIDs: &[]string{}},
+		{Id: "CreatePlease:task", Type: "Task", ParentID: "7", ChildrenIDs: &[]string{}},
+	} {
+		if _, err := tracker.generateRequestDict(&wobject); err != nil {
+			t.Errorf("generateRequestDict() of Id '%s' ParentID '%s' error = %v", wobject.Id, wobject.ParentID, err)
+		}
+	}
+	for _, wobject := range []Wobject{
+		{Id: "PROJ-2", Type: "Task", ParentID: "-1", ChildrenIDs: &[]string{}},
+		{Id: "12", Type: "Task", ParentID: "PROJ-1", ChildrenIDs: &[]string{}},
+	} {
+		_, err := tracker.generateRequestDict(&wobject)
+		var validationError *ValidationError
+		if !errors.As(err, &validationError) {
+			t.Errorf("generateRequestDict() of Id '%s' ParentID '%s' error = %v, want a ValidationError", wobject.Id, wobject.ParentID, err)
+		}
+	}
+}
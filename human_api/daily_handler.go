@@ -8,12 +8,15 @@ go run . --action hr_to_daily_json --src "daily_report_sample.hapi" --dst "daily
 package human_api
 
 import (
+	"bytes"
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -21,6 +24,8 @@ import (
 const delim = "!!=!!"
 
 type WorkerWobjReport struct {
+	// Notes are written as "// note" lines above the line.
+	Notes []string `json:"notes,omitempty"`
 	//parent and child: {type, id, title}
 	Parent       []string `json:"parent"`
 	Child        []string `json:"child"`
@@ -61,7 +66,7 @@ func ParseAPI() {
 		}
 	} else if *action == "hr_to_daily_json" {
 		log.Fatalf("Handling action '%v'", *action)
-		workers_daily, err := ConvertHRToDailyJson(*src, *dst)
+		workers_daily, err := ConvertHRToDailyJson(*src, *dst, DefaultWobjectTypes())
 		if err != nil || len(workers_daily) == 0 {
 			log.Fatal(err, workers_daily)
 		}
@@ -84,95 +89,139 @@ func ConvertDailyJsonToHR(src_file_path, dst_file_path string) (reports []Worker
 		return nil, err
 	}
 
-	WriteDailyToHRFile(reports, dst_file_path)
+	_, err = WriteDailyToHRFile(reports, dst_file_path)
+	if err != nil {
+		return nil, err
+	}
 
 	return reports, nil
 }
 
 func WriteDailyToHRFile(reports []WorkerDailyReport, dst_file_path string) (bool, error) {
 	log.Printf("Writing %d reports to '%s'", len(reports), dst_file_path)
-	worker_delim := fmt.Sprintf("%sH_ReportWorkerID%s", delim, delim)
-	file, err := os.OpenFile(dst_file_path, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
+	for _, report := range reports {
+		if CheckWorkerManaged(report.WorkerID) {
+			fmt.Printf("Writing worker report: '%v'\n", report.WorkerID)
+		}
+	}
+
+	data, err := FormatHapi(reports)
 	if err != nil {
 		return false, err
 	}
-	defer file.Close() // Ensure the file is closed when the function exits
+	err = os.WriteFile(dst_file_path, data, 0644)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Format reports as .hapi source, ParseHapi of the result returns the same reports.
+func FormatHapi(reports []WorkerDailyReport) ([]byte, error) {
+	worker_delim := fmt.Sprintf("%sH_ReportWorkerID%s", delim, delim)
+	var buffer bytes.Buffer
 
 	for _, report := range reports {
 		if !CheckWorkerManaged(report.WorkerID) {
 			continue
 		}
 
-		fmt.Printf("Writing worker report: '%v'\n", report.WorkerID)
-
 		line := fmt.Sprintf("%s %s\n", worker_delim, report.WorkerID)
-		if _, err := file.WriteString(line); err != nil {
-			return false, err
+		buffer.WriteString(line)
+
+		for _, section := range []struct {
+			status  string
+			reports []WorkerWobjReport
+		}{{"NEW", report.New}, {"ACTIVE", report.Active}, {"BLOCKED", report.Blocked}, {"CLOSED", report.Closed}} {
+			if _, err := WriteWorkerWobjStatusDailyToHRFile(&buffer, section.status, section.reports); err != nil {
+				return nil, err
+			}
 		}
-
-		WriteWorkerWobjStatusDailyToHRFile(file, "NEW", report.New)
-		WriteWorkerWobjStatusDailyToHRFile(file, "ACTIVE", report.Active)
-		WriteWorkerWobjStatusDailyToHRFile(file, "BLOCKED", report.Blocked)
-		WriteWorkerWobjStatusDailyToHRFile(file, "CLOSED", report.Closed)
-
 	}
-	return true, nil
+	return buffer.Bytes(), nil
 }
 
-func WriteWorkerWobjStatusDailyToHRFile(file *os.File, wobj_status string, wobj_reports []WorkerWobjReport) (bool, error) {
+func WriteWorkerWobjStatusDailyToHRFile(file io.Writer, wobj_status string, wobj_reports []WorkerWobjReport) (bool, error) {
 	line := fmt.Sprintf(">%s:\n", wobj_status)
-	if _, err := file.WriteString(line); err != nil {
+	if _, err := io.WriteString(file, line); err != nil {
 		return false, err
 	}
 	for _, wobj := range wobj_reports {
-		line = fmt.Sprintf("[%s %s #%s] %s -> ", wobj.Parent[0], wobj.Parent[1], wobj.Parent[2], delim)
-		if _, err := file.WriteString(line); err != nil {
-			return false, err
+		for _, note := range wobj.Notes {
+			if _, err := io.WriteString(file, hapiNotePrefix+" "+escapeHapiText(note)+"\n"); err != nil {
+				return false, err
+			}
 		}
 
-		line = fmt.Sprintf("%s %s #%s %s", wobj.Child[0], wobj.Child[1], wobj.Child[2], delim)
-		if _, err := file.WriteString(line); err != nil {
+		parent, err := formatHapiWobject(wobj.Parent)
+		if err != nil {
 			return false, err
 		}
-
-		actions_line := ""
-		if wobj.LeftTime != 0 {
-			actions_line = actions_line + strconv.Itoa(wobj.LeftTime)
+		child, err := formatHapiWobject(wobj.Child)
+		if err != nil {
+			return false, err
 		}
-
-		if wobj.InvestedTime != 0 {
-			if actions_line != "" {
-				actions_line = actions_line + ", +" + strconv.Itoa(wobj.InvestedTime)
-			} else {
-				actions_line = strconv.Itoa(wobj.InvestedTime)
-			}
+		actions, err := formatHapiActions(wobj)
+		if err != nil {
+			return false, err
 		}
 
-		if wobj.Comment != "" {
-			if actions_line != "" {
-				actions_line = actions_line + ", " + wobj.Comment
-			} else {
-				actions_line = wobj.Comment
-			}
+		line = fmt.Sprintf("[%s] %s -> %s %s Actions: %s\n", parent, delim, child, delim, actions)
+		if _, err := io.WriteString(file, line); err != nil {
+			return false, err
 		}
+	}
+	return true, nil
+}
 
-		actions_line = " Actions: " + actions_line + "\n"
-		if _, err := file.WriteString(actions_line); err != nil {
-			return false, err
+// Format {type, id, title}, an Id of -1 is no wobject.
+func formatHapiWobject(wobj []string) (string, error) {
+	if len(wobj) != 3 {
+		return "", fmt.Errorf("wobject expected {type, id, title}, got %v", wobj)
+	}
+	if wobj[1] == "-1" {
+		return "-1 #-1", nil
+	}
+	for _, token := range wobj[:2] {
+		if strings.ContainsAny(token, " \t\r\n#\\") {
+			return "", fmt.Errorf("invalid wobject type or id '%s' of '%s'", token, wobj[2])
 		}
+	}
 
+	head := wobj[0]
+	if wobj[1] != "" {
+		head = head + " " + wobj[1]
 	}
-	return true, nil
+	return head + " #" + escapeHapiText(wobj[2]), nil
+}
+
+// Times are written when set, -1 is not set.
+func formatHapiActions(wobj WorkerWobjReport) (string, error) {
+	if wobj.LeftTime < -1 || wobj.InvestedTime < -1 {
+		return "", fmt.Errorf("invalid times of '%v': left %d, invested %d", wobj.Child, wobj.LeftTime, wobj.InvestedTime)
+	}
+
+	parts := []string{}
+	if wobj.LeftTime != -1 {
+		parts = append(parts, strconv.Itoa(wobj.LeftTime))
+	}
+	if wobj.InvestedTime != -1 {
+		parts = append(parts, "+"+strconv.Itoa(wobj.InvestedTime))
+	}
+	if wobj.Comment != "" {
+		parts = append(parts, escapeHapiComment(wobj.Comment))
+	}
+	return strings.Join(parts, ", "), nil
 }
 
 func CheckWorkerManaged(worker_id string) bool {
 	return worker_id != ""
 }
 
-func ConvertHRToDailyJson(src_file_path, dst_file_path string) (reports []WorkerDailyReport, err error) {
+func ConvertHRToDailyJson(src_file_path, dst_file_path string, types WobjectTypes) (reports []WorkerDailyReport, err error) {
 	log.Printf("Called with src '%s' and dst '%s'", src_file_path, dst_file_path)
 
-	reports, err = ReadDailyFromHRFile(src_file_path)
+	reports, err = ReadDailyFromHRFile(src_file_path, types)
 	if err != nil {
 		return nil, err
 	}
@@ -190,317 +239,172 @@ func ConvertHRToDailyJson(src_file_path, dst_file_path string) (reports []Worker
 	return reports, nil
 }
 
-func ReadDailyFromHRFile(src_file_path string) ([]WorkerDailyReport, error) {
+// Every syntax error in the file is returned at once, as ParseErrors.
+func ReadDailyFromHRFile(src_file_path string, types WobjectTypes) ([]WorkerDailyReport, error) {
 	log.Printf("Reading reports from '%s'", src_file_path)
 	data, err := os.ReadFile(src_file_path)
 	if err != nil {
 		return nil, err
 	}
 
-	report_lines := strings.Split(string(data), "\n")
-	worker_chunks, err := SplitHapiLinesToWorkerChunks(report_lines)
-	if err != nil || len(worker_chunks) == 0 {
-		return nil, err
-	}
-	reports, err := ConvertWorkerChunksToWorkerDailyReports(worker_chunks)
-	if err != nil || len(reports) == 0 {
+	file, err := ParseHapi(src_file_path, data, types)
+	if err != nil {
 		return []WorkerDailyReport{}, err
 	}
 
-	return reports, nil
+	return file.WorkerDailyReports(), nil
 }
 
-func SplitHapiLinesToWorkerChunks(lines []string) (chunks [][]string, err error) {
-	worker_delim := fmt.Sprintf("%sH_ReportWorkerID%s", delim, delim)
-	var worker_chunk []string
-	var worker_chunks [][]string
-
-	for _, line := range lines {
-		if strings.Contains(line, worker_delim) && len(worker_chunk) > 0 {
-			log.Printf("Found '%s' contains '%s'", line, worker_delim)
-			worker_chunks = append(worker_chunks, worker_chunk)
-			worker_chunk = []string{}
+// Apply the lines the user changed in input, compared to base, to a newer base.
+// A changed line replaces the line of the same child in the newer base, lines
+// of new children are added to the section they were written in.
+func CarryOverReportEdits(base, input, newBase []WorkerDailyReport) []WorkerDailyReport {
+	baseLines := make(map[string]WorkerWobjReport)
+	for _, report := range base {
+		for sectionIndex, section := range reportSections(&report) {
+			for _, line := range *section {
+				baseLines[fmt.Sprintf("%d %s", sectionIndex, reportLineKey(report.WorkerID, line))] = line
+			}
 		}
-		worker_chunk = append(worker_chunk, line)
 	}
-	if len(worker_chunk) > 0 {
-		worker_chunks = append(worker_chunks, worker_chunk)
-	}
-
-	return worker_chunks, nil
-
-}
-
-func ConvertWorkerChunksToWorkerDailyReports(chunks [][]string) ([]WorkerDailyReport, error) {
-
-	var reports []WorkerDailyReport
 
-	for _, chunk := range chunks {
-		report, err := ConvertWorkerChunkToWorkerDailyReport(chunk)
-		if err != nil {
-			return reports, err
+	reports := []WorkerDailyReport{}
+	for _, report := range newBase {
+		for _, section := range reportSections(&report) {
+			*section = slices.Clone(*section)
 		}
 		reports = append(reports, report)
 	}
 
-	return reports, nil
-}
-
-func ConvertWorkerChunkToWorkerDailyReport(chunk []string) (report WorkerDailyReport, err error) {
-	/*
-		WorkerID string             `json:"worker_id"`
-		New      []WorkerWobjReport `json:"new"`
-		Active   []WorkerWobjReport `json:"active"`
-		Blocked  []WorkerWobjReport `json:"blocked"`
-		Closed   []WorkerWobjReport `json:"closed"`
-	*/
-
-	workerId, new, active, blocked, closed, err := SpitChunkByTypes(chunk)
-	if err != nil {
-		return report, err
-	}
-	fmt.Printf("%v, %v, %v, %v, %v", workerId, new, active, blocked, closed)
-	report.WorkerID = workerId
-	/*
-		Parent       []string `json:"parent"`
-		Child        []string `json:"child"`
-		Comment      string   `json:"comment"`
-		InvestedTime int      `json:"invested_time"`
-		LeftTime     int      `json:"left_time"`
-	*/
-
-	for _, newLine := range new {
-		workerWobjReport, err := GenerateWobjectReportFromHapiLine(newLine)
-		check(err)
-		report.New = append(report.New, workerWobjReport)
-	}
-	for _, newLine := range active {
-		workerWobjReport, err := GenerateWobjectReportFromHapiLine(newLine)
-		check(err)
-		report.Active = append(report.Active, workerWobjReport)
-	}
-	for _, newLine := range blocked {
-		workerWobjReport, err := GenerateWobjectReportFromHapiLine(newLine)
-		check(err)
-		report.Blocked = append(report.Blocked, workerWobjReport)
-	}
-	for _, newLine := range closed {
-		workerWobjReport, err := GenerateWobjectReportFromHapiLine(newLine)
-		check(err)
-		report.Closed = append(report.Closed, workerWobjReport)
-	}
-	return report, nil
-}
-
-func SpitChunkByTypes(lines []string) (id string, new, active, blocked, closed []string, err error) {
-	new, active, blocked, closed = []string{}, []string{}, []string{}, []string{}
-	worker_delim := fmt.Sprintf("%sH_ReportWorkerID%s", delim, delim)
-
-	var aggregator string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasSuffix(line, "\n") {
-			line = line[:len(line)-2]
-		}
-
-		if strings.Contains(line, worker_delim) {
-			id = line[len(worker_delim):]
-			continue
-		}
-		if line == "" {
-			continue
-		}
-		if line == ">NEW:" || line == ">ACTIVE:" || line == ">BLOCKED:" || line == ">CLOSED:" {
-			aggregator = line
-			continue
-		} else {
-			if aggregator == ">NEW:" {
-				new = append(new, line)
-			} else if aggregator == ">ACTIVE:" {
-				active = append(active, line)
-			} else if aggregator == ">BLOCKED:" {
-				blocked = append(blocked, line)
-			} else if aggregator == ">CLOSED:" {
-				closed = append(closed, line)
-			} else {
-				return id, new, active, blocked, closed, errors.New("Unknown state" + aggregator)
+	for _, inputReport := range input {
+		for sectionIndex, inputSection := range reportSections(&inputReport) {
+			for _, line := range *inputSection {
+				key := reportLineKey(inputReport.WorkerID, line)
+				if baseLine, ok := baseLines[fmt.Sprintf("%d %s", sectionIndex, key)]; ok && reflect.DeepEqual(baseLine, line) {
+					continue
+				}
+
+				workerIndex := slices.IndexFunc(reports, func(report WorkerDailyReport) bool {
+					return report.WorkerID == inputReport.WorkerID
+				})
+				if workerIndex == -1 {
+					reports = append(reports, WorkerDailyReport{WorkerID: inputReport.WorkerID})
+					workerIndex = len(reports) - 1
+				}
+				sections := reportSections(&reports[workerIndex])
+				for _, section := range sections {
+					*section = slices.DeleteFunc(*section, func(newLine WorkerWobjReport) bool {
+						return reportLineKey(inputReport.WorkerID, newLine) == key
+					})
+				}
+				*sections[sectionIndex] = append(*sections[sectionIndex], line)
 			}
-
 		}
 	}
-
-	return id, new, active, blocked, closed, nil
+	return reports
 }
 
-func GenerateWobjectReportFromHapiLine(line string) (WorkerWobjReport, error) {
-	// "[UserStory 1 #test User story] !!=!! -> Task 11 #test Task !!=!! Actions: 1, +1, Standard Comment",
-
-	log.Printf("GenerateWobjectReportFromHapiLine called with %s", line)
-	delim_count := strings.Count(line, delim)
-	if delim_count != 2 {
-		return WorkerWobjReport{}, fmt.Errorf("delimiters count expected 2, got %v in line '%v' ", delim_count, line)
-	}
-
-	line_parts := strings.Split(line, delim)
-	fmt.Printf("line_parts before parent: '%v'\n", line_parts)
-	parent_substring := line_parts[0]
-	parent_substring = strings.TrimLeft(parent_substring, " ")
-	parent_substring = strings.TrimRight(parent_substring, " ")
-	if !strings.HasPrefix(parent_substring, "[") || !strings.HasSuffix(parent_substring, "]") {
-		return WorkerWobjReport{}, fmt.Errorf("parent format is wrong: '%v'", parent_substring)
-	}
-	parent_substring = parent_substring[1 : len(parent_substring)-1]
-
-	parent, err := SplitReportWobjectSubLineToTokens(parent_substring)
-	if err != nil {
-		return WorkerWobjReport{}, err
-	}
-	fmt.Printf("line_parts after parent: '%v'\n", line_parts)
-	child_substring := line_parts[1]
-	child_substring = strings.TrimLeft(child_substring, " ")
-	child_substring = strings.TrimRight(child_substring, " ")
-	if !strings.HasPrefix(child_substring, "->") {
-		return WorkerWobjReport{}, fmt.Errorf("child format is wrong: '%v'", child_substring)
-	}
-	child_substring = child_substring[2:]
-	child, err := SplitReportWobjectSubLineToTokens(child_substring)
-	if err != nil {
-		return WorkerWobjReport{}, err
-	}
-
-	actions := strings.TrimLeft(line_parts[2], " ")
-	if !strings.HasPrefix(actions, "Actions:") {
-		return WorkerWobjReport{}, fmt.Errorf("wrong format, missing 'Actions:' in suffix: '%v'", actions)
-	}
-	actions = actions[len("Actions:"):]
-	actions = strings.TrimLeft(actions, " ")
-	actions = strings.TrimRight(actions, " ")
+// Sections of a report in NEW, ACTIVE, BLOCKED, CLOSED order.
+func reportSections(report *WorkerDailyReport) []*[]WorkerWobjReport {
+	return []*[]WorkerWobjReport{&report.New, &report.Active, &report.Blocked, &report.Closed}
+}
 
-	lef_time, invested_time, comment, err1 := GenerateWobjectActionsFromHapiSubLine(actions)
-	if err1 != nil {
-		return WorkerWobjReport{}, err1
+// Lines are matched by the child Id, children without an Id by their title.
+func reportLineKey(workerID string, line WorkerWobjReport) string {
+	if line.Child[1] != "" {
+		return line.Child[1]
 	}
+	return workerID + " #" + line.Child[2]
+}
 
-	int_invested_time := -1
-	if invested_time != "" {
-		int_invested_time, err = strconv.Atoi(invested_time)
-		if err != nil {
-			return WorkerWobjReport{}, err
+// Pre-fill today's base report from the previous day's reports.
+// Lines keep the order the worker gave them in the previous input, then the
+// order of the previous base. Lines are compared with the previous input, the
+// report the worker submitted, so their own changes are not reported back:
+// unchanged lines get its comment, lines that changed or are new since then
+// get a note.
+func PrefillReportsFromPreviousDay(previousBase, previousInput, base []WorkerDailyReport) []WorkerDailyReport {
+	type previousLine struct {
+		sectionIndex int
+		line         WorkerWobjReport
+	}
+	indexLines := func(reports []WorkerDailyReport) map[string]previousLine {
+		lines := make(map[string]previousLine)
+		for _, report := range reports {
+			for sectionIndex, section := range reportSections(&report) {
+				for _, line := range *section {
+					lines[reportLineKey(report.WorkerID, line)] = previousLine{sectionIndex, line}
+				}
+			}
 		}
-	}
-
-	int_lef_time := -1
-	if lef_time != "" {
-		int_lef_time, err = strconv.Atoi(lef_time)
-		if err != nil {
-			return WorkerWobjReport{}, err
+		return lines
+	}
+	previousInputLines := indexLines(previousInput)
+
+	inputOrder := make(map[string]int)
+	for _, reports := range [][]WorkerDailyReport{previousInput, previousBase} {
+		for _, report := range reports {
+			for _, section := range reportSections(&report) {
+				for _, line := range *section {
+					if _, ok := inputOrder[reportLineKey(report.WorkerID, line)]; !ok {
+						inputOrder[reportLineKey(report.WorkerID, line)] = len(inputOrder)
+					}
+				}
+			}
 		}
 	}
 
-	wobj := WorkerWobjReport{
-		Parent:       parent[:],
-		Child:        child[:],
-		Comment:      comment,
-		InvestedTime: int_invested_time,
-		LeftTime:     int_lef_time,
-	}
-	return wobj, nil
-}
-
-func SplitReportWobjectSubLineToTokens(line string) ([3]string, error) {
-	//"UserStory 1 #test User story
-	// Task 11 #test Task
-	// Task #test Task
-	// UserStory #title
-	// UserStory -1 #title
-	//log.Printf("SplitReportWobjectSubLineToTokens Called with '%s'", line)
-	line = strings.TrimLeft(line, " ")
-	chunks := strings.Split(line, " ")
-	wobj_type := chunks[0]
-
-	// Has no parent.
-	if line == "-1 #-1" {
-		return [3]string{"-1", "-1", "-1"}, nil
-	}
-
-	if wobj_type != "UserStory" && wobj_type != "Task" && wobj_type != "Feature" && wobj_type != "DevOpsSupport" && wobj_type != "EscapedBug" {
-		return [3]string{"", "", ""}, fmt.Errorf("generateWobjectFromHapiSubLine unsupported Wobject type: '%s' in line '%s'", wobj_type, line)
-	}
-
-	wobj_id := ""
-	var title string
-	var title_left []string
+	reports := []WorkerDailyReport{}
+	for _, report := range base {
+		for sectionIndex, section := range reportSections(&report) {
+			lines := slices.Clone(*section)
+			for index, line := range lines {
+				key := reportLineKey(report.WorkerID, line)
+				previous, ok := previousInputLines[key]
+				if !ok {
+					lines[index].Notes = []string{"new since the previous report"}
+					continue
+				}
+				changes := describeReportLineChanges(previous.sectionIndex, previous.line, sectionIndex, line)
+				if len(changes) > 0 {
+					lines[index].Notes = []string{"changed since the previous report: " + strings.Join(changes, ", ")}
+					continue
+				}
+				lines[index].Comment = previous.line.Comment
+			}
 
-	if strings.HasPrefix(chunks[1], "#") {
-		wobj_id = ""
-		title = chunks[1][1:]
-		title_left = chunks[2:]
-	} else {
-		wobj_id = chunks[1]
-		//lint:ignore SA4017 // Return alue is not used
-		if !(strings.HasPrefix(chunks[2], "#")) {
+			// Ordered lines keep that order, the rest follow in base order.
+			slices.SortStableFunc(lines, func(a, b WorkerWobjReport) int {
+				orderA, okA := inputOrder[reportLineKey(report.WorkerID, a)]
+				orderB, okB := inputOrder[reportLineKey(report.WorkerID, b)]
+				switch {
+				case okA && okB:
+					return orderA - orderB
+				case okA:
+					return -1
+				case okB:
+					return 1
+				}
+				return 0
+			})
+			*section = lines
 		}
-		title = chunks[2][1:]
-		title_left = chunks[3:]
-	}
-	if len(title_left) > 0 {
-		title += " " + strings.Join(title_left, " ")
-	}
-	//todo:
-	if wobj_type != "UserStory" && wobj_type != "Task" && wobj_type != "Feature" && wobj_type != "DevOpsSupport" && wobj_type != "EscapedBug" {
-		return [3]string{"", "", ""}, fmt.Errorf("SplitReportWobjectSubLineToTokens unsupported Wobject type: '%s'", wobj_type)
+		reports = append(reports, report)
 	}
-
-	return [3]string{wobj_type, wobj_id, title}, nil
+	return reports
 }
 
-func GenerateWobjectActionsFromHapiSubLine(line string) (lef_time, invested_time, comment string, err error) {
-	line = strings.TrimLeft(line, " ")
-	line = strings.TrimRight(line, " ")
-	if len(line) == 0 {
-		return lef_time, invested_time, comment, nil
+func describeReportLineChanges(previousSectionIndex int, previous WorkerWobjReport, sectionIndex int, line WorkerWobjReport) []string {
+	sectionNames := []string{"NEW", "ACTIVE", "BLOCKED", "CLOSED"}
+	changes := []string{}
+	if previousSectionIndex != sectionIndex {
+		changes = append(changes, fmt.Sprintf("status %s -> %s", sectionNames[previousSectionIndex], sectionNames[sectionIndex]))
 	}
-
-	lst_parts := strings.Split(line, ",")
-
-	first_char := lst_parts[0][0]
-
-	_, err = strconv.Atoi(string(first_char))
-
-	if err == nil {
-		firstPart := strings.TrimLeft(lst_parts[0], " ")
-		firstPart = strings.TrimRight(firstPart, " ")
-		number, errConvert := strconv.Atoi(firstPart)
-		if errConvert != nil {
-			return lef_time, invested_time, comment, errConvert
-		}
-		lef_time = strconv.Itoa(number)
-		lst_parts = lst_parts[1:]
-	}
-
-	if len(lst_parts) == 0 {
-		return lef_time, invested_time, comment, nil
-	}
-
-	firstPart := strings.TrimLeft(lst_parts[0], " ")
-	firstPart = strings.TrimRight(firstPart, " ")
-	first_char = firstPart[0]
-	if first_char == '+' {
-		firstPart = firstPart[1:]
-		number, errConvert := strconv.Atoi(firstPart)
-		if errConvert != nil {
-			return lef_time, invested_time, comment, errConvert
-		}
-		invested_time = strconv.Itoa(number)
-		lst_parts = lst_parts[1:]
+	if previous.Child[2] != line.Child[2] {
+		changes = append(changes, fmt.Sprintf("title '%s' -> '%s'", previous.Child[2], line.Child[2]))
 	}
-
-	if len(lst_parts) == 0 {
-		return lef_time, invested_time, comment, nil
+	if previous.Parent[1] != line.Parent[1] {
+		changes = append(changes, fmt.Sprintf("parent %s -> %s", previous.Parent[1], line.Parent[1]))
 	}
-	comment = strings.Join(lst_parts, ",")
-	comment = strings.TrimSuffix(strings.TrimLeft(comment, " "), " ")
-
-	return lef_time, invested_time, comment, nil
+	return changes
 }
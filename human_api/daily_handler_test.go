@@ -45,7 +45,7 @@ var test_WorkerDailyReports = []WorkerDailyReport{
 				Parent:       []string{"UserStory", "1", "test User story"},
 				Child:        []string{"Task", "12", "test Task 2"},
 				Comment:      "start_comment Standard, Comment end_comment",
-				InvestedTime: 0,
+				InvestedTime: -1,
 				LeftTime:     1,
 			},
 		},
@@ -55,7 +55,7 @@ var test_WorkerDailyReports = []WorkerDailyReport{
 				Child:        []string{"Task", "22", "test Task 22"},
 				Comment:      "start_comment Standard, Comment end_comment",
 				InvestedTime: 1,
-				LeftTime:     0,
+				LeftTime:     -1,
 			},
 		},
 		Blocked: []WorkerWobjReport{
@@ -63,8 +63,8 @@ var test_WorkerDailyReports = []WorkerDailyReport{
 				Parent:       []string{"UserStory", "2", "test User story2"},
 				Child:        []string{"Task", "23", "test Task 23"},
 				Comment:      "start_comment Standard, Comment end_comment",
-				InvestedTime: 0,
-				LeftTime:     0,
+				InvestedTime: -1,
+				LeftTime:     -1,
 			},
 		},
 		Closed: []WorkerWobjReport{
@@ -72,8 +72,8 @@ var test_WorkerDailyReports = []WorkerDailyReport{
 				Parent:       []string{"UserStory", "3", "test User story3"},
 				Child:        []string{"Task", "31", "test Task 31"},
 				Comment:      "",
-				InvestedTime: 0,
-				LeftTime:     0,
+				InvestedTime: -1,
+				LeftTime:     -1,
 			},
 		},
 	},
@@ -154,13 +154,13 @@ func TestConvertDailyJsonToHR(t *testing.T) {
 	}{
 		{
 			name:     "Valid JSON invalid data",
-			filename: "daily_report_sample.json", // Create this test file
+			filename: "test_data/daily_report_sample.json",
 			want:     []WorkerDailyReport{test_WorkerDailyReport},
 			wantErr:  true,
 		},
 		{
 			name:     "Valid JSON valid data",
-			filename: "daily_report_sample.json", // Create this test file
+			filename: "test_data/daily_report_sample.json",
 			want:     test_WorkerDailyReports,
 			wantErr:  false,
 		},
@@ -168,7 +168,7 @@ func TestConvertDailyJsonToHR(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ConvertDailyJsonToHR(tt.filename, "dst_file_path")
+			got, err := ConvertDailyJsonToHR(tt.filename, filepath.Join(t.TempDir(), "daily_report_sample.hapi"))
 
 			if (err != nil) && !tt.wantErr {
 				t.Errorf("readItemsFromFile() error = %v, wantErr %v", err, tt.wantErr)
@@ -242,187 +242,3 @@ func TestWriteWorkerWobjStatusDailyToHRFile(t *testing.T) {
 	})
 
 }
-
-func TestSplitHapiLinesToWorkerChunks(t *testing.T) {
-	t.Run("Valid input", func(t *testing.T) {
-		var testLines = []string{"!!=!!H_ReportWorkerID!!=!!Horey1", "1", "!!=!!H_ReportWorkerID!!=!!Horey2", "2"}
-		var wantedChunks = [][]string{{"!!=!!H_ReportWorkerID!!=!!Horey1", "1"}, {"!!=!!H_ReportWorkerID!!=!!Horey2", "2"}}
-		chunks, err := SplitHapiLinesToWorkerChunks(testLines)
-		if err != nil {
-			t.Errorf("Test failed: %s", err)
-		}
-		if !reflect.DeepEqual(chunks, wantedChunks) {
-			t.Errorf("ReadDailyFromHRFile() = %v, want %v", chunks, wantedChunks)
-		}
-	})
-}
-
-func TestSpitChunkByTypes(t *testing.T) {
-	t.Run("Valid input", func(t *testing.T) {
-		var testLines = []string{"!!=!!H_ReportWorkerID!!=!!Horey1",
-			">NEW:",
-			"1",
-			"2",
-			">ACTIVE:",
-			"3",
-			">BLOCKED:",
-			">CLOSED:",
-			"4",
-			"5",
-		}
-
-		want_new, want_active, want_blocked, want_closed := []string{"1", "2"}, []string{"3"}, []string{}, []string{"4", "5"}
-		want_id := "Horey1"
-
-		id, new, active, blocked, closed, err := SpitChunkByTypes(testLines)
-		if err != nil {
-			t.Errorf("Test failed: %s", err)
-		}
-		if id != want_id ||
-			!reflect.DeepEqual(new, want_new) ||
-			!reflect.DeepEqual(active, want_active) ||
-			!reflect.DeepEqual(blocked, want_blocked) ||
-			!reflect.DeepEqual(closed, want_closed) {
-			t.Errorf("SpitChunkByTypes() = '%v', '%v', '%v', '%v', '%v' want '%v', '%v', '%v', '%v', '%v' ", id, new, active, blocked, closed, want_id, want_new, want_active, want_blocked, want_closed)
-		}
-	})
-}
-
-func TestGenerateWobjectReportFromHapiLine(t *testing.T) {
-	t.Run("Valid input", func(t *testing.T) {
-
-		testCases := []struct {
-			inputLine string
-			want      WorkerWobjReport
-			wantErr   bool
-		}{
-			{
-				inputLine: "[UserStory 100 #test User story] !!=!! -> Task 1100 #test Task !!=!! Actions: 1, +1, Standard Comment",
-				want: WorkerWobjReport{
-					Parent:       []string{"UserStory", "100", "test User story"},
-					Child:        []string{"Task", "1100", "test Task"},
-					Comment:      "Standard Comment",
-					InvestedTime: 1,
-					LeftTime:     1,
-				},
-				wantErr: false,
-			},
-		}
-
-		for _, testCase := range testCases {
-			got, err := GenerateWobjectReportFromHapiLine(testCase.inputLine)
-			if err != nil && !testCase.wantErr {
-				t.Errorf("GenerateWobjectReportFromHapiLine() error= %v", err)
-			}
-			if !reflect.DeepEqual(got, testCase.want) && !testCase.wantErr {
-				t.Errorf("GenerateWobjectReportFromHapiLine() = '%v', want %v", got.Comment, testCase.want)
-			}
-
-		}
-	})
-}
-
-func TestSplitReportWobjectSubLineToTokens(t *testing.T) {
-	t.Run("Valid input", func(t *testing.T) {
-		testCases := []struct {
-			inputLine string
-			want      [3]string
-			wantErr   bool
-		}{
-			{
-				inputLine: "UserStory 1 #test User story",
-				want:      [3]string{"UserStory", "1", "test User story"},
-				wantErr:   false,
-			},
-			{
-				inputLine: "Task 11 #test task",
-				want:      [3]string{"Task", "11", "test task"},
-				wantErr:   false,
-			},
-			{
-				inputLine: "Task #test task",
-				want:      [3]string{"Task", "", "test task"},
-				wantErr:   false,
-			},
-			{
-				inputLine: "UserStory #title",
-				want:      [3]string{"UserStory", "", "title"},
-				wantErr:   false,
-			},
-			{
-				inputLine: "UserStory -1 #title",
-				want:      [3]string{"UserStory", "-1", "title"},
-				wantErr:   false,
-			},
-			{
-				inputLine: "Feature #title",
-				want:      [3]string{"UserStory", "-1", "title"},
-				wantErr:   true,
-			},
-		}
-
-		for _, testCase := range testCases {
-			got, err := SplitReportWobjectSubLineToTokens(testCase.inputLine)
-			if err != nil && !testCase.wantErr {
-				t.Errorf("SplitReportWobjectSubLineToTokens() error= %v", err)
-			}
-			if !reflect.DeepEqual(got, testCase.want) && !testCase.wantErr {
-				t.Errorf("SplitReportWobjectSubLineToTokens() = %v, want %v", got, testCase.want)
-			}
-
-		}
-	})
-}
-
-func TestGenerateWobjectActionsFromHapiSubLine(t *testing.T) {
-	t.Run("Valid input", func(t *testing.T) {
-		testCases := []struct {
-			inputLine string
-			want      []string
-			wantErr   bool
-		}{
-			{
-				inputLine: "1, +1, Standard Comment",
-				want:      []string{"1", "1", "Standard Comment"},
-				wantErr:   false,
-			},
-			{
-				inputLine: "1, +2, Standard Comment",
-				want:      []string{"1", "2", "Standard Comment"},
-				wantErr:   false,
-			},
-			{
-				inputLine: "1, start_comment Standard, Comment end_comment",
-				want:      []string{"1", "", "start_comment Standard, Comment end_comment"},
-				wantErr:   false,
-			},
-			{
-				inputLine: "start_comment Standard, Comment end_comment",
-				want:      []string{"", "", "start_comment Standard, Comment end_comment"},
-				wantErr:   false,
-			},
-			{
-				inputLine: "+3, start_comment Standard, Comment end_comment",
-				want:      []string{"", "3", "start_comment Standard, Comment end_comment"},
-				wantErr:   false,
-			},
-			{
-				inputLine: "",
-				want:      []string{"", "", ""},
-				wantErr:   false,
-			},
-		}
-
-		for _, testCase := range testCases {
-			lef_time, invested_time, comment, err := GenerateWobjectActionsFromHapiSubLine(testCase.inputLine)
-			got := []string{lef_time, invested_time, comment}
-			if err != nil && !testCase.wantErr {
-				t.Errorf("GenerateWobjectActionsFromHapiSubLine() error= %v", err)
-			}
-			if !reflect.DeepEqual(got, testCase.want) && !testCase.wantErr {
-				t.Errorf("GenerateWobjectActionsFromHapiSubLine() = %v, want %v", got, testCase.want)
-			}
-
-		}
-	})
-}
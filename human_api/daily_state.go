@@ -0,0 +1,296 @@
+package human_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const dailyStateFileName = "daily_state.json"
+
+// Phases of the daily routine, in lifecycle order.
+const (
+	PhaseEmpty      = "empty"
+	PhaseDownloaded = "downloaded"
+	PhaseEditing    = "editing"
+	PhaseSubmitting = "submitting"
+	PhaseDone       = "done"
+)
+
+// Allowed phase transitions. Backward transitions are resets, they are not
+// allowed once the submit started changing the tracker. Submitting goes back
+// to editing only while the journal records no tracker change.
+var dailyTransitions = map[string][]string{
+	PhaseEmpty:      {PhaseDownloaded},
+	PhaseDownloaded: {PhaseEditing, PhaseEmpty},
+	PhaseEditing:    {PhaseSubmitting, PhaseEditing, PhaseDownloaded, PhaseEmpty},
+	PhaseSubmitting: {PhaseDone, PhaseEditing},
+	PhaseDone:       {},
+}
+
+// Files of a daily routine directory.
+type dailyFiles struct {
+	DirPath    string
+	PreReport  string
+	Input      string
+	Base       string
+	PostReport string
+	Journal    string
+	State      string
+}
+
+func newDailyFiles(dirPath string) dailyFiles {
+	return dailyFiles{
+		DirPath:    dirPath,
+		PreReport:  filepath.Join(dirPath, preReportFileName),
+		Input:      filepath.Join(dirPath, inputFileName),
+		Base:       filepath.Join(dirPath, baseFileName),
+		PostReport: filepath.Join(dirPath, postReportFileName),
+		Journal:    filepath.Join(dirPath, journalFileName),
+		State:      filepath.Join(dirPath, dailyStateFileName),
+	}
+}
+
+// Files every phase requires to exist.
+func (files dailyFiles) required(phase string) []string {
+	switch phase {
+	case PhaseDownloaded:
+		return []string{files.PreReport}
+	case PhaseEditing, PhaseSubmitting:
+		return []string{files.PreReport, files.Base, files.Input}
+	case PhaseDone:
+		return []string{files.PostReport}
+	}
+	return []string{}
+}
+
+type DailyTransition struct {
+	From string    `json:"From"`
+	To   string    `json:"To"`
+	At   time.Time `json:"At"`
+}
+
+// DailyState is the phase of a daily routine directory, kept in daily_state.json.
+type DailyState struct {
+	Phase     string            `json:"Phase"`
+	UpdatedAt time.Time         `json:"UpdatedAt"`
+	History   []DailyTransition `json:"History"`
+	filePath  string
+}
+
+// Load the state of a daily directory. Directories written before the state
+// file existed get their phase from the files in them.
+func LoadDailyState(files dailyFiles) (*DailyState, error) {
+	state := &DailyState{filePath: files.State, History: []DailyTransition{}}
+	exists, err := checkFileExists(files.State)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		state.Phase, err = inferDailyPhase(files)
+		if err != nil {
+			return nil, err
+		}
+		return state, nil
+	}
+
+	data, err := os.ReadFile(files.State)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return nil, fmt.Errorf("malformed daily state '%s': %v", files.State, err)
+	}
+	if _, ok := dailyTransitions[state.Phase]; !ok {
+		return nil, fmt.Errorf("unknown daily phase '%s' in '%s'", state.Phase, files.State)
+	}
+	return state, nil
+}
+
+// The phase of the latest file in the directory. A journal recording no tracker
+// change is not a started submit.
+func inferDailyPhase(files dailyFiles) (string, error) {
+	journal, err := LoadJournal(files.Journal)
+	if err != nil {
+		return "", err
+	}
+	phaseFiles := []struct {
+		phase    string
+		filePath string
+	}{{PhaseDone, files.PostReport}, {PhaseSubmitting, files.Journal}, {PhaseEditing, files.Input}, {PhaseDownloaded, files.PreReport}}
+	for _, phaseFile := range phaseFiles {
+		if phaseFile.phase == PhaseSubmitting && !journal.Started() {
+			continue
+		}
+		exists, err := checkFileExists(phaseFile.filePath)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return phaseFile.phase, nil
+		}
+	}
+	return PhaseEmpty, nil
+}
+
+// Check that the files of the phase exist.
+func (state *DailyState) Validate(files dailyFiles) error {
+	for _, filePath := range files.required(state.Phase) {
+		exists, err := checkFileExists(filePath)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("daily phase '%s' requires missing file '%s', run -action status to recover", state.Phase, filePath)
+		}
+	}
+	return nil
+}
+
+// Move to the next phase and write the state file.
+func (state *DailyState) Transition(phase string) error {
+	if !slices.Contains(dailyTransitions[state.Phase], phase) {
+		return fmt.Errorf("daily phase can not change from '%s' to '%s'", state.Phase, phase)
+	}
+
+	now := time.Now()
+	log.Printf("daily phase: %s -> %s\n", state.Phase, phase)
+	state.History = append(state.History, DailyTransition{From: state.Phase, To: phase, At: now})
+	state.Phase = phase
+	state.UpdatedAt = now
+
+	jsonData, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(state.filePath, jsonData, 0644)
+}
+
+// DailyStatus describes today's daily directory for the status command.
+type DailyStatus struct {
+	DirPath   string
+	Phase     string
+	UpdatedAt time.Time
+	Problem   string
+	Next      string
+	Recovery  []string
+}
+
+func (status DailyStatus) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Daily directory: %s\n", status.DirPath)
+	fmt.Fprintf(&builder, "Phase: %s", status.Phase)
+	if !status.UpdatedAt.IsZero() {
+		fmt.Fprintf(&builder, " (since %s)", status.UpdatedAt.Format("2006-01-02 15:04:05"))
+	}
+	builder.WriteString("\n")
+	if status.Problem != "" {
+		fmt.Fprintf(&builder, "Problem: %s\n", status.Problem)
+	}
+	fmt.Fprintf(&builder, "Next step: %s\n", status.Next)
+	if len(status.Recovery) > 0 {
+		builder.WriteString("Recover:\n")
+		for _, recovery := range status.Recovery {
+			fmt.Fprintf(&builder, "  %s\n", recovery)
+		}
+	}
+	return builder.String()
+}
+
+func GetDailyStatus(files dailyFiles) (DailyStatus, error) {
+	state, err := LoadDailyState(files)
+	if err != nil {
+		return DailyStatus{}, err
+	}
+
+	status := DailyStatus{DirPath: files.DirPath, Phase: state.Phase, UpdatedAt: state.UpdatedAt}
+	if err := state.Validate(files); err != nil {
+		status.Problem = err.Error()
+	}
+
+	resetDownloaded := "-action reset -phase downloaded   regenerate base.hapi and input.hapi from pre_report.json, dropping the edits"
+	resetEmpty := "-action reset -phase empty        download again and regenerate, dropping the edits"
+	redownload := "-action redownload               download again, keeping the edits made in input.hapi"
+	resetEditing := "-action reset -phase editing     the submit did not change the tracker, edit input.hapi again"
+	switch state.Phase {
+	case PhaseEmpty:
+		status.Next = "-action daily downloads pre_report.json and generates base.hapi and input.hapi"
+	case PhaseDownloaded:
+		status.Next = "-action daily generates base.hapi and input.hapi from pre_report.json"
+		status.Recovery = []string{resetEmpty}
+	case PhaseEditing:
+		status.Next = "edit input.hapi, preview with -action daily -plan, submit with -action daily"
+		status.Recovery = []string{redownload, resetDownloaded, resetEmpty}
+	case PhaseSubmitting:
+		journal, err := LoadJournal(files.Journal)
+		if err != nil {
+			return DailyStatus{}, err
+		}
+		status.Next = "-action daily resumes the submit, steps in " + journalFileName + " are skipped"
+		if !journal.Started() {
+			status.Recovery = []string{resetEditing}
+			break
+		}
+		status.Recovery = []string{"the tracker was already changed, fix the failing step and rerun -action daily",
+			"on conflicts with remote changes, edit input.hapi to keep the remote values and rerun -action daily"}
+	case PhaseDone:
+		status.Next = "nothing, the daily routine is done"
+	}
+	return status, nil
+}
+
+// Go back to an earlier phase, removing the files generated after it. A submit
+// that did not change the tracker goes back to editing, keeping the edits.
+func ResetDailyPhase(files dailyFiles, phase string) error {
+	state, err := LoadDailyState(files)
+	if err != nil {
+		return err
+	}
+	if phase == PhaseEditing {
+		return resetDailySubmit(files, state)
+	}
+	if phase != PhaseEmpty && phase != PhaseDownloaded {
+		return fmt.Errorf("can reset to '%s', '%s' or '%s' only, got '%s'", PhaseEmpty, PhaseDownloaded, PhaseEditing, phase)
+	}
+	if !slices.Contains(dailyTransitions[state.Phase], phase) {
+		return fmt.Errorf("daily phase '%s' can not be reset to '%s'", state.Phase, phase)
+	}
+
+	removed := []string{files.Input, files.Base,
+		strings.Replace(files.Input, ".hapi", "_hapi.json", 1),
+		strings.Replace(files.Base, ".hapi", "_hapi.json", 1)}
+	if phase == PhaseEmpty {
+		removed = append(removed, files.PreReport)
+	}
+	for _, filePath := range removed {
+		err = os.Remove(filePath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return state.Transition(phase)
+}
+
+func resetDailySubmit(files dailyFiles, state *DailyState) error {
+	if state.Phase != PhaseSubmitting {
+		return fmt.Errorf("can reset to '%s' from the '%s' phase only, the phase is '%s'", PhaseEditing, PhaseSubmitting, state.Phase)
+	}
+	journal, err := LoadJournal(files.Journal)
+	if err != nil {
+		return err
+	}
+	if journal.Started() {
+		return fmt.Errorf("the submit already changed the tracker, rerun -action daily to resume it")
+	}
+	err = os.Remove(files.Journal)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return state.Transition(PhaseEditing)
+}
@@ -0,0 +1,213 @@
+package human_api
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDailyRoutinePhases(t *testing.T) {
+	t.Run("Extract, reset, submit", func(t *testing.T) {
+		configFilePath, config := writeLocalTestConfig(t)
+		files := newDailyFiles(filepath.Join(config.ReportsDirPath, config.SprintName, time.Now().Format("2006_01_02")))
+		assertPhase := func(want string) {
+			t.Helper()
+			status, err := DailyRoutineStatus(configFilePath)
+			if err != nil {
+				t.Fatalf("DailyRoutineStatus() error = %v", err)
+			}
+			if status.Phase != want || status.Problem != "" {
+				t.Fatalf("DailyRoutineStatus() =\n%s\nwant phase %s", status, want)
+			}
+		}
+
+		assertPhase(PhaseEmpty)
+		err := DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() extract error = %v", err)
+		}
+		assertPhase(PhaseEditing)
+
+		err = DailyRoutineReset(configFilePath, PhaseDownloaded)
+		if err != nil {
+			t.Fatalf("DailyRoutineReset() error = %v", err)
+		}
+		inputExists, _ := checkFileExists(files.Input)
+		preReportExists, _ := checkFileExists(files.PreReport)
+		if inputExists || !preReportExists {
+			t.Fatalf("DailyRoutineReset() left input or removed pre report")
+		}
+		assertPhase(PhaseDownloaded)
+
+		err = DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() regenerate error = %v", err)
+		}
+		assertPhase(PhaseEditing)
+
+		err = DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() submit error = %v", err)
+		}
+		assertPhase(PhaseDone)
+
+		err = DailyRoutineReset(configFilePath, PhaseEmpty)
+		if err == nil {
+			t.Errorf("DailyRoutineReset() after done error = nil")
+		}
+
+		state, err := LoadDailyState(files)
+		if err != nil {
+			t.Fatalf("LoadDailyState() error = %v", err)
+		}
+		phases := []string{}
+		for _, transition := range state.History {
+			phases = append(phases, transition.To)
+		}
+		want := "downloaded editing downloaded editing submitting done"
+		if strings.Join(phases, " ") != want {
+			t.Errorf("DailyState.History = %v, want %s", phases, want)
+		}
+	})
+
+	t.Run("Missing input is reported", func(t *testing.T) {
+		configFilePath, config := writeLocalTestConfig(t)
+		files := newDailyFiles(filepath.Join(config.ReportsDirPath, config.SprintName, time.Now().Format("2006_01_02")))
+		err := DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() extract error = %v", err)
+		}
+		err = os.Remove(files.Input)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		status, err := DailyRoutineStatus(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutineStatus() error = %v", err)
+		}
+		if !strings.Contains(status.Problem, files.Input) || len(status.Recovery) == 0 {
+			t.Errorf("DailyRoutineStatus() =\n%s", status)
+		}
+		if DailyRoutine(configFilePath) == nil {
+			t.Errorf("DailyRoutine() with missing input error = nil")
+		}
+	})
+
+	t.Run("Failed submit goes back to editing", func(t *testing.T) {
+		configFilePath, config := writeLocalTestConfig(t)
+		files := newDailyFiles(filepath.Join(config.ReportsDirPath, config.SprintName, time.Now().Format("2006_01_02")))
+		editLocalTestInput(t, configFilePath, files, "3, +2, half way")
+		changeLocalStore(t, config, func(wobjects map[string]*Wobject) {
+			wobjects["2"].LeftTime = 4
+		})
+		if DailyRoutine(configFilePath) == nil {
+			t.Fatalf("DailyRoutine() submit of a conflict error = nil")
+		}
+		state, err := LoadDailyState(files)
+		if err != nil || state.Phase != PhaseEditing {
+			t.Fatalf("LoadDailyState() after a conflict = %+v, %v", state, err)
+		}
+
+		// Directories stuck in submitting before the first step succeeded.
+		err = state.Transition(PhaseSubmitting)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		status, err := DailyRoutineStatus(configFilePath)
+		if err != nil || len(status.Recovery) != 1 || !strings.Contains(status.Recovery[0], "-action reset -phase editing") {
+			t.Errorf("DailyRoutineStatus() = %s, %v", status, err)
+		}
+		err = DailyRoutineReset(configFilePath, PhaseEditing)
+		if err != nil {
+			t.Fatalf("DailyRoutineReset() error = %v", err)
+		}
+		err = os.Remove(files.State)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		state, err = LoadDailyState(files)
+		if err != nil || state.Phase != PhaseEditing {
+			t.Errorf("LoadDailyState() inferred = %+v, %v", state, err)
+		}
+
+		writeLocalTestInput(t, files, ">ACTIVE:\n", "4, +2, half way")
+		err = DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() submit error = %v", err)
+		}
+		state, err = LoadDailyState(files)
+		if err != nil || state.Phase != PhaseDone || state.History[len(state.History)-2].To != PhaseSubmitting {
+			t.Errorf("LoadDailyState() after the submit = %+v, %v", state, err)
+		}
+		if DailyRoutineReset(configFilePath, PhaseEditing) == nil {
+			t.Errorf("DailyRoutineReset() of a done directory error = nil")
+		}
+	})
+
+	t.Run("Unreadable directory is an error", func(t *testing.T) {
+		// The daily directory is a file, checking the files in it fails.
+		filePath := filepath.Join(t.TempDir(), "2024_03_05")
+		err := os.WriteFile(filePath, []byte{}, 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		_, err = GetDailyStatus(newDailyFiles(filePath))
+		if err == nil || !strings.Contains(err.Error(), "failed checking file exists") {
+			t.Errorf("GetDailyStatus() error = %v", err)
+		}
+	})
+}
+
+func TestDailyRoutineRedownload(t *testing.T) {
+	t.Run("Keeps the edits", func(t *testing.T) {
+		configFilePath, config := writeLocalTestConfig(t)
+		files := newDailyFiles(filepath.Join(config.ReportsDirPath, config.SprintName, time.Now().Format("2006_01_02")))
+		err := DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() extract error = %v", err)
+		}
+
+		data, err := os.ReadFile(files.Input)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		editedLine := "[UserStory 1 #story] !!=!! -> Task 2 #task !!=!! Actions: 3, +2, half way\n"
+		input := strings.Replace(string(data), "[UserStory 1 #story] !!=!! -> Task 2 #task !!=!! Actions: \n", "", 1)
+		input = strings.Replace(input, ">ACTIVE:\n", ">ACTIVE:\n"+editedLine, 1)
+		err = os.WriteFile(files.Input, []byte(input), 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		wobjects, err := readLocalStore(config.LocalStoreFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		wobjects["4"] = &Wobject{Id: "4", Title: "remote task", WorkerID: "horey", ParentID: "1", Priority: 2, Status: "New", Sprint: "sp1", Type: "Task"}
+		err = writeLocalStore(wobjects, config.LocalStoreFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = DailyRoutineRedownload(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutineRedownload() error = %v", err)
+		}
+
+		data, err = os.ReadFile(files.Input)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if !strings.Contains(string(data), ">ACTIVE:\n"+editedLine) ||
+			strings.Contains(string(data), "Task 2 #task !!=!! Actions: \n") ||
+			!strings.Contains(string(data), "Task 4 #remote task") {
+			t.Errorf("DailyRoutineRedownload() input:\n%s", data)
+		}
+		if exists, err := checkFileExists(files.Input + ".bak"); !exists {
+			t.Errorf("DailyRoutineRedownload() did not back up the input: %v", err)
+		}
+	})
+}
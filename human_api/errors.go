@@ -0,0 +1,123 @@
+package human_api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError reports a malformed line of a .hapi file.
+// Line and Column are 1-based, 0 when not known.
+type ParseError struct {
+	File   string
+	Line   int
+	Column int
+	Text   string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	position := e.File
+	if e.Line != 0 {
+		position = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	if e.Line != 0 && e.Column != 0 {
+		position = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+	if position == "" {
+		return fmt.Sprintf("%v in line '%s'", e.Err, e.Text)
+	}
+	return fmt.Sprintf("%s: %v", position, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists every problem found in the reports or the user input.
+type ValidationError struct {
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("input Validation errors:\n %v", strings.Join(e.Errors, "\n"))
+}
+
+func newValidationError(format string, a ...any) *ValidationError {
+	return &ValidationError{Errors: []string{fmt.Sprintf(format, a...)}}
+}
+
+// UnmappedStatesError lists the work items left out of a snapshot because no
+// StateMapping covers their type or state. The other work items are read.
+type UnmappedStatesError struct {
+	Errors []string
+}
+
+func (e *UnmappedStatesError) Error() string {
+	return fmt.Sprintf("%d work items are not reported, add their states to StateMappings:\n %v", len(e.Errors), strings.Join(e.Errors, "\n "))
+}
+
+// TrackerError wraps an error returned by a Tracker call.
+// Op is the Tracker operation, WobjectId is empty for download.
+type TrackerError struct {
+	Op        string
+	WobjectId string
+	Err       error
+}
+
+func (e *TrackerError) Error() string {
+	if e.WobjectId == "" {
+		return fmt.Sprintf("tracker %s failed: %v", e.Op, e.Err)
+	}
+	return fmt.Sprintf("tracker %s of wobject '%s' failed: %v", e.Op, e.WobjectId, e.Err)
+}
+
+func (e *TrackerError) Unwrap() error {
+	return e.Err
+}
+
+// MergeConflict is a field changed both in the input and remotely since the download.
+type MergeConflict struct {
+	WobjectId string
+	Field     string
+	Base      string
+	Input     string
+	Remote    string
+}
+
+// ConflictError lists the merge conflicts found before a submit, nothing was submitted.
+type ConflictError struct {
+	Conflicts []MergeConflict
+}
+
+func (e *ConflictError) Error() string {
+	lines := []string{}
+	for _, conflict := range e.Conflicts {
+		lines = append(lines, fmt.Sprintf("wobject '%s' %s: base '%s', input '%s', remote '%s'",
+			conflict.WobjectId, conflict.Field, conflict.Base, conflict.Input, conflict.Remote))
+	}
+	return fmt.Sprintf("%d conflicts with remote changes, redownload or edit the input:\n %v", len(e.Conflicts), strings.Join(lines, "\n "))
+}
+
+// TransitionError is a state change rejected by the tracker process. From is
+// empty for a created wobject, Reasons are the rules the change broke.
+type TransitionError struct {
+	WobjectId string
+	Type      string
+	From      string
+	To        string
+	Reasons   []string
+	Err       error
+}
+
+func (e *TransitionError) Error() string {
+	from := e.From
+	if from == "" {
+		from = "created"
+	}
+	return fmt.Sprintf("%s '%s' can not move from state '%s' to '%s':\n %s\nchange its status in the input or add the required fields to the Transitions of its StateMappings",
+		e.Type, e.WobjectId, from, e.To, strings.Join(e.Reasons, "\n "))
+}
+
+func (e *TransitionError) Unwrap() error {
+	return e.Err
+}
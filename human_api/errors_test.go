@@ -0,0 +1,65 @@
+package human_api
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tracker failing every update.
+type failingTracker struct {
+	recordingTracker
+}
+
+func (tracker *failingTracker) UpdateWobject(wobject *Wobject) error {
+	return errors.New("service unavailable")
+}
+
+func TestReadDailyFromHRFileParseError(t *testing.T) {
+	t.Run("Malformed actions", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "input.hapi")
+		data := "!!=!!H_ReportWorkerID!!=!! horey\n" +
+			">NEW:\n" +
+			">ACTIVE:\n" +
+			"[UserStory 1 #story] !!=!! -> Task 2 #task !!=!! Actions: 3, +x, comment\n"
+		err := os.WriteFile(filePath, []byte(data), 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		_, err = ReadDailyFromHRFile(filePath, DefaultWobjectTypes())
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			t.Fatalf("ReadDailyFromHRFile() error = %v, want *ParseError", err)
+		}
+		if parseError.File != filePath || parseError.Line != 4 {
+			t.Errorf("ReadDailyFromHRFile() error position = %s:%d, want %s:4", parseError.File, parseError.Line, filePath)
+		}
+	})
+}
+
+func TestFilterChangedWobjectsValidationError(t *testing.T) {
+	t.Run("Wobject missing in base", func(t *testing.T) {
+		inputWobjects := map[string]*Wobject{"2": {Id: "2", Title: "task", ChildrenIDs: &[]string{}}}
+		_, err := FilterChangedWobjects(map[string]*Wobject{}, inputWobjects)
+		var validationError *ValidationError
+		if !errors.As(err, &validationError) || len(validationError.Errors) == 0 {
+			t.Fatalf("FilterChangedWobjects() error = %v, want *ValidationError", err)
+		}
+	})
+}
+
+func TestSubmitWobjectsTrackerError(t *testing.T) {
+	t.Run("Update fails", func(t *testing.T) {
+		wobjects := []*Wobject{{Id: "11", Title: "task", ParentID: "-1", ChildrenIDs: &[]string{}}}
+		err := SubmitWobjects(&failingTracker{}, wobjects)
+		var trackerError *TrackerError
+		if !errors.As(err, &trackerError) {
+			t.Fatalf("SubmitWobjects() error = %v, want *TrackerError", err)
+		}
+		if trackerError.Op != "update" || trackerError.WobjectId != "11" {
+			t.Errorf("SubmitWobjects() error = %+v", trackerError)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package human_api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AlexeyBeley/human_api/github_api"
+)
+
+const trackerGithub = "github"
+
+// Tracker implementation backed by github_api.
+// Wobject ids are issue numbers, milestones are sprints and sub-issues are children.
+// GitHub has no time tracking, so times are reported in the issue comments.
+type GithubTracker struct {
+	Config github_api.Configuration
+}
+
+func NewGithubTracker(config github_api.Configuration) *GithubTracker {
+	return &GithubTracker{Config: config}
+}
+
+func (tracker *GithubTracker) Download(dstFilePath string) error {
+	return github_api.DownloadMilestoneIssues(tracker.Config, dstFilePath)
+}
+
+func (tracker *GithubTracker) DownloadWobjects(wobjectIds []string, dstFilePath string) error {
+	numbers := []int{}
+	for _, wobjectId := range wobjectIds {
+		number, err := strconv.Atoi(wobjectId)
+		if err != nil {
+			return fmt.Errorf("github issue number '%s': %v", wobjectId, err)
+		}
+		numbers = append(numbers, number)
+	}
+	return github_api.DownloadIssues(tracker.Config, numbers, dstFilePath)
+}
+
+func (tracker *GithubTracker) ReadWobjects(srcFilePath string) (map[string]*Wobject, error) {
+	snapshot, err := github_api.ReadSnapshotFromFile(srcFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertGithubSnapshotToWobjects(tracker.Config, snapshot), nil
+}
+
+func (tracker *GithubTracker) CreateWobject(wobject *Wobject) error {
+	issue, err := github_api.CreateIssue(tracker.Config, wobject.Title, wobject.Description, getGithubAssignees(wobject))
+	if err != nil {
+		return err
+	}
+	wobject.Id = strconv.Itoa(issue.Number)
+
+	if wobject.Status != "" && wobject.Status != "New" {
+		err = github_api.SetIssueStatus(tracker.Config, issue.Number, wobject.Status)
+		if err != nil {
+			return err
+		}
+	}
+
+	comment := formatGithubProgressComment(wobject, "")
+	if comment == "" {
+		return nil
+	}
+	return github_api.AddComment(tracker.Config, issue.Number, comment)
+}
+
+func (tracker *GithubTracker) UpdateWobject(wobject *Wobject) error {
+	number, err := strconv.Atoi(wobject.Id)
+	if err != nil {
+		return fmt.Errorf("wobject [%s] [%s] is not a GitHub issue number: %v", wobject.Id, wobject.Title, err)
+	}
+
+	err = github_api.UpdateIssue(tracker.Config, number, map[string]interface{}{
+		"title":     wobject.Title,
+		"assignees": getGithubAssignees(wobject),
+	})
+	if err != nil {
+		return err
+	}
+
+	if wobject.Status != "" {
+		err = github_api.SetIssueStatus(tracker.Config, number, wobject.Status)
+		if err != nil {
+			return err
+		}
+	}
+
+	comment := formatGithubProgressComment(wobject, wobject.Description)
+	if comment == "" {
+		return nil
+	}
+	return github_api.AddComment(tracker.Config, number, comment)
+}
+
+func (tracker *GithubTracker) SetWobjectParent(wobject *Wobject) error {
+	number, err := strconv.Atoi(wobject.Id)
+	if err != nil {
+		return fmt.Errorf("wobject [%s] [%s] is not a GitHub issue number: %v", wobject.Id, wobject.Title, err)
+	}
+	parentNumber, err := strconv.Atoi(wobject.ParentID)
+	if err != nil {
+		return fmt.Errorf("wobject [%s] [%s] parent '%s' is not a GitHub issue number: %v", wobject.Id, wobject.Title, wobject.ParentID, err)
+	}
+	return github_api.SetIssueParent(tracker.Config, number, parentNumber)
+}
+
+func getGithubAssignees(wobject *Wobject) []string {
+	if wobject.WorkerID == "" {
+		return []string{}
+	}
+	return []string{wobject.WorkerID}
+}
+
+// Comment text with the reported times appended, empty if there is nothing to report.
+func formatGithubProgressComment(wobject *Wobject, comment string) string {
+	// Unchanged comment.
+	if comment == "-1" {
+		comment = ""
+	}
+	times := []string{}
+	if wobject.InvestedTime > 0 {
+		times = append(times, fmt.Sprintf("invested: %dh", wobject.InvestedTime))
+	}
+	if wobject.LeftTime != -1 {
+		times = append(times, fmt.Sprintf("left: %dh", wobject.LeftTime))
+	}
+
+	if len(times) == 0 {
+		return comment
+	}
+	if comment == "" {
+		return strings.Join(times, ", ")
+	}
+	return comment + "\n\n" + strings.Join(times, ", ")
+}
+
+func ConvertGithubSnapshotToWobjects(config github_api.Configuration, snapshot github_api.Snapshot) map[string]*Wobject {
+	wobjects := make(map[string]*Wobject)
+	for _, issue := range snapshot.Issues {
+		wobject := ConvertGithubIssueToWobject(config, issue)
+		wobject.Sprint = snapshot.Milestone.Title
+		wobjects[wobject.Id] = &wobject
+	}
+	for _, issue := range snapshot.Parents {
+		wobject := ConvertGithubIssueToWobject(config, issue)
+		wobjects[wobject.Id] = &wobject
+	}
+
+	for wobjId, wobject := range wobjects {
+		if wobject.ParentID == "" {
+			continue
+		}
+		parent, ok := wobjects[wobject.ParentID]
+		if !ok {
+			continue
+		}
+		*(parent.ChildrenIDs) = append(*(parent.ChildrenIDs), wobjId)
+	}
+
+	// Issues without an explicit type are stories when they have sub-issues.
+	for _, wobject := range wobjects {
+		if wobject.Type != "" {
+			continue
+		}
+		wobject.Type = "Task"
+		if len(*wobject.ChildrenIDs) != 0 {
+			wobject.Type = "UserStory"
+		}
+	}
+	return wobjects
+}
+
+func ConvertGithubIssueToWobject(config github_api.Configuration, issue github_api.Issue) (wobject Wobject) {
+	wobject.Id = strconv.Itoa(issue.Number)
+	wobject.Title = issue.Title
+	wobject.ChildrenIDs = &[]string{}
+	wobject.Status = github_api.GetIssueStatus(config, issue)
+
+	if issue.ParentNumber != 0 {
+		wobject.ParentID = strconv.Itoa(issue.ParentNumber)
+	}
+	if len(issue.Assignees) != 0 {
+		wobject.WorkerID = issue.Assignees[0].Login
+	} else if issue.User != nil {
+		wobject.WorkerID = issue.User.Login
+	}
+	if issue.Type != nil {
+		wobject.Type = strings.Replace(issue.Type.Name, " ", "", -1)
+	}
+	return wobject
+}
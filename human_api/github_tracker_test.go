@@ -0,0 +1,54 @@
+package human_api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AlexeyBeley/human_api/github_api"
+)
+
+func TestConvertGithubSnapshotToWobjects(t *testing.T) {
+	t.Run("Milestone issues and parents", func(t *testing.T) {
+		snapshot := github_api.Snapshot{
+			Milestone: github_api.Milestone{Number: 3, Title: "Sprint 2"},
+			Issues: []github_api.Issue{
+				{Number: 2, Title: "task 2", State: "open", ParentNumber: 1, Assignees: []github_api.User{{Login: "horey"}}, Labels: []github_api.Label{{Name: "blocked"}}},
+			},
+			Parents: []github_api.Issue{
+				{Number: 1, Title: "story", State: "open", User: &github_api.User{Login: "horey"}},
+			},
+		}
+
+		wobjects := ConvertGithubSnapshotToWobjects(github_api.Configuration{}, snapshot)
+
+		wantTask := Wobject{Id: "2", Title: "task 2", WorkerID: "horey", ChildrenIDs: &[]string{}, ParentID: "1", Status: "Blocked", Sprint: "Sprint 2", Type: "Task"}
+		wantStory := Wobject{Id: "1", Title: "story", WorkerID: "horey", ChildrenIDs: &[]string{"2"}, Status: "New", Type: "UserStory"}
+		if !reflect.DeepEqual(*wobjects["2"], wantTask) {
+			t.Errorf("ConvertGithubSnapshotToWobjects() task = %+v, want %+v", *wobjects["2"], wantTask)
+		}
+		if !reflect.DeepEqual(*wobjects["1"], wantStory) {
+			t.Errorf("ConvertGithubSnapshotToWobjects() story = %+v, want %+v", *wobjects["1"], wantStory)
+		}
+	})
+}
+
+func TestFormatGithubProgressComment(t *testing.T) {
+	t.Run("Times appended", func(t *testing.T) {
+		testCases := []struct {
+			wobject Wobject
+			comment string
+			want    string
+		}{
+			{Wobject{InvestedTime: 2, LeftTime: 3}, "done the API", "done the API\n\ninvested: 2h, left: 3h"},
+			{Wobject{InvestedTime: -1, LeftTime: -1}, "done the API", "done the API"},
+			{Wobject{InvestedTime: -1, LeftTime: -1}, "", ""},
+			{Wobject{InvestedTime: 1, LeftTime: -1}, "", "invested: 1h"},
+		}
+		for _, testCase := range testCases {
+			got := formatGithubProgressComment(&testCase.wobject, testCase.comment)
+			if got != testCase.want {
+				t.Errorf("formatGithubProgressComment() = %q, want %q", got, testCase.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,524 @@
+package human_api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The .hapi grammar. Every construct is a single line, blank lines are skipped
+// and spaces around tokens are optional:
+//
+//	file    = { worker }
+//	worker  = "!!=!!H_ReportWorkerID!!=!!" workerId { section }
+//	section = ( ">NEW:" | ">ACTIVE:" | ">BLOCKED:" | ">CLOSED:" ) { { note } line }
+//	note    = "//" text
+//	line    = "[" parent "]" "!!=!!" "->" wobject "!!=!!" "Actions:" actions
+//	parent  = wobject | "-1 #-1"
+//	wobject = type [ id ] "#" text
+//	actions = [ left "," ] [ "+" invested "," ] [ comment ]
+//
+// The last "," of actions is omitted when nothing follows it. Times are
+// decimal, a missing time is -1. The comment is the rest of the line; it is
+// escaped text that does not start with a digit or "+".
+//
+// Text is escaped: "\\" is a backslash, "\n", "\r" and "\t" are control
+// characters, "\s" is a space and "\u{hex}" is any code point. Any other
+// escaped character stands for itself, so "\!!=!!" is a delimiter inside a
+// title. The writer escapes backslashes, control characters, delimiters and
+// whitespace at either end of the text, so parsing a written report gives
+// the same report back.
+
+// Position of a node in a .hapi file. Line and Column are 1-based, Column counts bytes.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (pos Position) String() string {
+	return fmt.Sprintf("%s:%d:%d", pos.File, pos.Line, pos.Column)
+}
+
+// HapiFile is the AST of a .hapi file.
+type HapiFile struct {
+	Workers []*HapiWorker
+}
+
+// HapiWorker is a "!!=!!H_ReportWorkerID!!=!! worker" header and the sections after it.
+type HapiWorker struct {
+	Pos      Position
+	WorkerID string
+	Sections []*HapiSection
+}
+
+// HapiSection is a ">NEW:", ">ACTIVE:", ">BLOCKED:" or ">CLOSED:" header and its lines.
+type HapiSection struct {
+	Pos    Position
+	Status string
+	Lines  []*HapiLine
+}
+
+// HapiLine is "[parent] !!=!! -> child !!=!! Actions: left, +invested, comment".
+// Notes are the "// note" lines written right before it.
+type HapiLine struct {
+	Pos     Position
+	Notes   []string
+	Parent  HapiWobject
+	Child   HapiWobject
+	Actions HapiActions
+}
+
+// HapiWobject is "Type Id #Title", the Id is optional. "-1 #-1" is no wobject.
+type HapiWobject struct {
+	Pos   Position
+	Type  string
+	Id    string
+	Title string
+}
+
+// HapiActions times are -1 when not set.
+type HapiActions struct {
+	Pos          Position
+	LeftTime     int
+	InvestedTime int
+	Comment      string
+}
+
+// ParseErrors is every syntax error found in a file, in line order.
+type ParseErrors []*ParseError
+
+func (errs ParseErrors) Error() string {
+	lines := []string{}
+	for _, err := range errs {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (errs ParseErrors) Unwrap() []error {
+	ret := []error{}
+	for _, err := range errs {
+		ret = append(ret, err)
+	}
+	return ret
+}
+
+var hapiSectionStatuses = map[string]string{
+	">NEW:":     "NEW",
+	">ACTIVE:":  "ACTIVE",
+	">BLOCKED:": "BLOCKED",
+	">CLOSED:":  "CLOSED",
+}
+
+// Lines starting with it are notes for the user, attached to the next line.
+const hapiNotePrefix = "//"
+
+// Parse .hapi source. A malformed line is reported and skipped, so the
+// returned ParseErrors list every syntax error in the file. Wobject types
+// must be in the types registry.
+func ParseHapi(fileName string, src []byte, types WobjectTypes) (*HapiFile, error) {
+	workerDelim := fmt.Sprintf("%sH_ReportWorkerID%s", delim, delim)
+	file := &HapiFile{}
+	var errs ParseErrors
+	var worker *HapiWorker
+	var section *HapiSection
+	notes := []string{}
+
+	for index, text := range strings.Split(string(src), "\n") {
+		text = strings.TrimSuffix(text, "\r")
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" {
+			continue
+		}
+		pos := Position{File: fileName, Line: index + 1, Column: strings.Index(text, trimmed) + 1}
+
+		if note, ok := strings.CutPrefix(trimmed, hapiNotePrefix); ok {
+			note, err := unescapeHapiText(strings.TrimSpace(note))
+			if err != nil {
+				errs = append(errs, newHapiParseError(pos, text, "%v", err))
+				continue
+			}
+			notes = append(notes, note)
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, workerDelim) {
+			worker = &HapiWorker{Pos: pos, WorkerID: strings.TrimSpace(trimmed[len(workerDelim):])}
+			if worker.WorkerID == "" {
+				errs = append(errs, newHapiParseError(pos, text, "expected worker id after '%s'", workerDelim))
+			}
+			file.Workers = append(file.Workers, worker)
+			section = nil
+			continue
+		}
+
+		if status, ok := hapiSectionStatuses[trimmed]; ok {
+			if worker == nil {
+				errs = append(errs, newHapiParseError(pos, text, "expected '%s' before '%s'", workerDelim, trimmed))
+				worker = &HapiWorker{Pos: pos}
+				file.Workers = append(file.Workers, worker)
+			}
+			section = &HapiSection{Pos: pos, Status: status}
+			worker.Sections = append(worker.Sections, section)
+			continue
+		}
+
+		if section == nil {
+			errs = append(errs, newHapiParseError(pos, text, "expected >NEW:, >ACTIVE:, >BLOCKED: or >CLOSED: before line"))
+			continue
+		}
+
+		line, err := parseHapiLine(pos, text, types)
+		lineNotes := notes
+		notes = []string{}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(lineNotes) > 0 {
+			line.Notes = lineNotes
+		}
+		section.Lines = append(section.Lines, line)
+	}
+
+	if len(errs) > 0 {
+		return file, errs
+	}
+	return file, nil
+}
+
+func newHapiParseError(pos Position, text string, format string, a ...any) *ParseError {
+	return &ParseError{File: pos.File, Line: pos.Line, Column: pos.Column, Text: strings.TrimSpace(text), Err: fmt.Errorf(format, a...)}
+}
+
+// Scanner over a single line, keeping the byte offset for column numbers.
+type hapiLineScanner struct {
+	pos    Position
+	text   string
+	offset int
+}
+
+func (scanner *hapiLineScanner) skipSpaces() {
+	for scanner.offset < len(scanner.text) && scanner.text[scanner.offset] == ' ' {
+		scanner.offset++
+	}
+}
+
+func (scanner *hapiLineScanner) position() Position {
+	pos := scanner.pos
+	pos.Column = scanner.offset + 1
+	return pos
+}
+
+func (scanner *hapiLineScanner) errorf(format string, a ...any) *ParseError {
+	return newHapiParseError(scanner.position(), scanner.text, format, a...)
+}
+
+// Consume the token after optional spaces.
+func (scanner *hapiLineScanner) expect(token string, context string) *ParseError {
+	scanner.skipSpaces()
+	if !strings.HasPrefix(scanner.text[scanner.offset:], token) {
+		return scanner.errorf("expected '%s' %s", token, context)
+	}
+	scanner.offset += len(token)
+	return nil
+}
+
+// Consume everything up to the unescaped terminator, which is left in place.
+func (scanner *hapiLineScanner) until(terminator string, context string) (string, Position, *ParseError) {
+	scanner.skipSpaces()
+	start := scanner.position()
+	end := indexUnescaped(scanner.text[scanner.offset:], terminator)
+	if end == -1 {
+		scanner.offset = len(scanner.text)
+		return "", start, scanner.errorf("expected '%s' %s", terminator, context)
+	}
+	value := scanner.text[scanner.offset : scanner.offset+end]
+	scanner.offset += end
+	return value, start, nil
+}
+
+func parseHapiLine(pos Position, text string, types WobjectTypes) (*HapiLine, *ParseError) {
+	scanner := &hapiLineScanner{pos: pos, text: text, offset: pos.Column - 1}
+	line := &HapiLine{Pos: pos}
+
+	err := scanner.expect("[", "before parent")
+	if err != nil {
+		return nil, err
+	}
+	value, valuePos, err := scanner.until(delim, "after parent")
+	if err != nil {
+		return nil, err
+	}
+	value = strings.TrimRight(value, " ")
+	if !strings.HasSuffix(value, "]") {
+		errPos := valuePos
+		errPos.Column += len(value)
+		return nil, newHapiParseError(errPos, text, "expected ']' after parent")
+	}
+	line.Parent, err = parseHapiWobject(valuePos, text, value[:len(value)-1], types)
+	if err != nil {
+		return nil, err
+	}
+
+	err = scanner.expect(delim, "after parent")
+	if err != nil {
+		return nil, err
+	}
+	err = scanner.expect("->", "after parent")
+	if err != nil {
+		return nil, err
+	}
+	value, valuePos, err = scanner.until(delim, "after child")
+	if err != nil {
+		return nil, err
+	}
+	line.Child, err = parseHapiWobject(valuePos, text, strings.TrimRight(value, " "), types)
+	if err != nil {
+		return nil, err
+	}
+	scanner.offset += len(delim)
+
+	err = scanner.expect("Actions:", "after child")
+	if err != nil {
+		return nil, err
+	}
+	scanner.skipSpaces()
+	line.Actions, err = parseHapiActions(scanner.position(), text, strings.TrimRight(text[scanner.offset:], " "))
+	if err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// Parse "Type Id #Title" starting at pos.
+func parseHapiWobject(pos Position, text string, value string, types WobjectTypes) (HapiWobject, *ParseError) {
+	wobject := HapiWobject{Pos: pos}
+	if strings.TrimSpace(value) == "-1 #-1" {
+		wobject.Type, wobject.Id, wobject.Title = "-1", "-1", "-1"
+		return wobject, nil
+	}
+
+	titleIndex := strings.Index(value, "#")
+	if titleIndex == -1 {
+		errPos := pos
+		errPos.Column += len(value)
+		return wobject, newHapiParseError(errPos, text, "expected '#' before title")
+	}
+	title, err := unescapeHapiText(strings.TrimSpace(value[titleIndex+1:]))
+	if err != nil {
+		errPos := pos
+		errPos.Column += titleIndex + 1
+		return wobject, newHapiParseError(errPos, text, "%v", err)
+	}
+	wobject.Title = title
+
+	fields := strings.Fields(value[:titleIndex])
+	if len(fields) == 0 {
+		return wobject, newHapiParseError(pos, text, "expected wobject type before title")
+	}
+	if len(fields) > 2 {
+		errPos := pos
+		errPos.Column += strings.Index(value, fields[2])
+		return wobject, newHapiParseError(errPos, text, "unexpected '%s', expected '#' before title", fields[2])
+	}
+	wobject.Type = fields[0]
+	if _, ok := types.Get(wobject.Type); !ok {
+		return wobject, newHapiParseError(pos, text, "unsupported wobject type '%s'", wobject.Type)
+	}
+	if len(fields) == 2 {
+		wobject.Id = fields[1]
+	}
+	return wobject, nil
+}
+
+// Parse "left, +invested, comment", every part is optional.
+func parseHapiActions(pos Position, text string, value string) (HapiActions, *ParseError) {
+	actions := HapiActions{Pos: pos, LeftTime: -1, InvestedTime: -1}
+	offset := 0
+	nextPart := func() (string, Position) {
+		partPos := pos
+		rest := value[offset:]
+		end := strings.Index(rest, ",")
+		if end == -1 {
+			end = len(rest)
+		}
+		part := rest[:end]
+		partPos.Column += offset + len(part) - len(strings.TrimLeft(part, " "))
+		return strings.TrimSpace(part), partPos
+	}
+	consume := func() {
+		end := strings.Index(value[offset:], ",")
+		if end == -1 {
+			offset = len(value)
+			return
+		}
+		offset += end + 1
+	}
+
+	part, partPos := nextPart()
+	if part != "" && part[0] >= '0' && part[0] <= '9' {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return actions, newHapiParseError(partPos, text, "invalid left time '%s'", part)
+		}
+		actions.LeftTime = number
+		consume()
+	}
+
+	part, partPos = nextPart()
+	if strings.HasPrefix(part, "+") {
+		number, err := strconv.Atoi(part[1:])
+		if err != nil {
+			return actions, newHapiParseError(partPos, text, "invalid invested time '%s'", part)
+		}
+		actions.InvestedTime = number
+		consume()
+	}
+
+	_, partPos = nextPart()
+	comment, err := unescapeHapiText(strings.TrimSpace(value[offset:]))
+	if err != nil {
+		return actions, newHapiParseError(partPos, text, "%v", err)
+	}
+	actions.Comment = comment
+	return actions, nil
+}
+
+// Index of the first sep in text not escaped by a backslash, -1 if there is none.
+func indexUnescaped(text string, sep string) int {
+	for offset := 0; ; {
+		index := strings.Index(text[offset:], sep)
+		if index == -1 {
+			return -1
+		}
+		index += offset
+		backslashes := 0
+		for i := index - 1; i >= 0 && text[i] == '\\'; i-- {
+			backslashes++
+		}
+		if backslashes%2 == 0 {
+			return index
+		}
+		offset = index + 1
+	}
+}
+
+// Escape text for a title, a comment or a note, see the grammar above.
+func escapeHapiText(text string) string {
+	var builder strings.Builder
+	for offset := 0; offset < len(text); {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		atEnd := offset == 0 || offset+size == len(text)
+		switch {
+		case r == '\\':
+			builder.WriteString(`\\`)
+		case r == '\n':
+			builder.WriteString(`\n`)
+		case r == '\r':
+			builder.WriteString(`\r`)
+		case r == '\t':
+			builder.WriteString(`\t`)
+		case r == ' ' && atEnd:
+			builder.WriteString(`\s`)
+		case atEnd && unicode.IsSpace(r):
+			fmt.Fprintf(&builder, `\u{%x}`, r)
+		case strings.HasPrefix(text[offset:], delim):
+			builder.WriteString(`\!`)
+		default:
+			builder.WriteString(text[offset : offset+size])
+		}
+		offset += size
+	}
+	return builder.String()
+}
+
+// Escape a comment, which must not be read as a time.
+func escapeHapiComment(comment string) string {
+	escaped := escapeHapiText(comment)
+	if escaped != "" && (escaped[0] == '+' || (escaped[0] >= '0' && escaped[0] <= '9')) {
+		return `\` + escaped
+	}
+	return escaped
+}
+
+func unescapeHapiText(text string) (string, error) {
+	if !strings.Contains(text, `\`) {
+		return text, nil
+	}
+
+	var builder strings.Builder
+	for offset := 0; offset < len(text); offset++ {
+		if text[offset] != '\\' {
+			builder.WriteByte(text[offset])
+			continue
+		}
+		offset++
+		if offset == len(text) {
+			return "", fmt.Errorf("unterminated escape at the end of '%s'", text)
+		}
+		switch text[offset] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 't':
+			builder.WriteByte('\t')
+		case 's':
+			builder.WriteByte(' ')
+		case 'u':
+			end := strings.IndexByte(text[offset:], '}')
+			if !strings.HasPrefix(text[offset:], "u{") || end == -1 {
+				return "", fmt.Errorf("expected '\\u{hex}' in '%s'", text)
+			}
+			code, err := strconv.ParseUint(text[offset+2:offset+end], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", fmt.Errorf("invalid code point '%s' in '%s'", text[offset-1:offset+end+1], text)
+			}
+			builder.WriteRune(rune(code))
+			offset += end
+		default:
+			builder.WriteByte(text[offset])
+		}
+	}
+	return builder.String(), nil
+}
+
+// Convert the AST to the reports the daily routine works on.
+func (file *HapiFile) WorkerDailyReports() []WorkerDailyReport {
+	reports := []WorkerDailyReport{}
+	for _, worker := range file.Workers {
+		report := WorkerDailyReport{WorkerID: worker.WorkerID}
+		for _, section := range worker.Sections {
+			for _, line := range section.Lines {
+				wobjReport := line.WorkerWobjReport()
+				switch section.Status {
+				case "NEW":
+					report.New = append(report.New, wobjReport)
+				case "ACTIVE":
+					report.Active = append(report.Active, wobjReport)
+				case "BLOCKED":
+					report.Blocked = append(report.Blocked, wobjReport)
+				case "CLOSED":
+					report.Closed = append(report.Closed, wobjReport)
+				}
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+func (line *HapiLine) WorkerWobjReport() WorkerWobjReport {
+	return WorkerWobjReport{
+		Notes:        line.Notes,
+		Parent:       []string{line.Parent.Type, line.Parent.Id, line.Parent.Title},
+		Child:        []string{line.Child.Type, line.Child.Id, line.Child.Title},
+		Comment:      line.Actions.Comment,
+		InvestedTime: line.Actions.InvestedTime,
+		LeftTime:     line.Actions.LeftTime,
+	}
+}
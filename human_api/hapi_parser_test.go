@@ -0,0 +1,179 @@
+package human_api
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHapi(t *testing.T) {
+	t.Run("Sample input", func(t *testing.T) {
+		srcFilePath := "test_data/daily_report_sample_input.hapi"
+		data, err := os.ReadFile(srcFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		file, err := ParseHapi(srcFilePath, data, DefaultWobjectTypes())
+		if err != nil {
+			t.Fatalf("ParseHapi() error = %v", err)
+		}
+
+		comment := "start_comment Standard, Comment end_comment"
+		want := WorkerDailyReport{
+			WorkerID: "horey",
+			New: []WorkerWobjReport{
+				{Parent: []string{"UserStory", "1", "test User story"}, Child: []string{"Task", "11", "test Task"}, Comment: "Standard Comment", InvestedTime: 1, LeftTime: 1},
+				{Parent: []string{"UserStory", "1", "test User story"}, Child: []string{"Task", "12", "test Task 2"}, Comment: comment, InvestedTime: -1, LeftTime: 1},
+			},
+			Active: []WorkerWobjReport{
+				{Parent: []string{"UserStory", "2", "test User story2"}, Child: []string{"Task", "22", "test Task 22"}, Comment: comment, InvestedTime: -1, LeftTime: 1},
+			},
+			Blocked: []WorkerWobjReport{
+				{Parent: []string{"UserStory", "2", "test User story2"}, Child: []string{"Task", "23", "test Task 23"}, Comment: comment, InvestedTime: -1, LeftTime: -1},
+			},
+			Closed: []WorkerWobjReport{
+				{Parent: []string{"UserStory", "3", "test User story3"}, Child: []string{"Task", "31", "test Task 31"}, InvestedTime: -1, LeftTime: -1},
+			},
+		}
+		got := file.WorkerDailyReports()
+		if len(got) != 2 || got[1].WorkerID != "horey1" || !reflect.DeepEqual(got[0], want) {
+			t.Errorf("ParseHapi() reports = %+v, want %+v", got, want)
+		}
+		line := file.Workers[1].Sections[0].Lines[1]
+		if line.Pos.Line != 15 || line.Child.Pos.Column != 43 || line.Child.Id != "121" {
+			t.Errorf("ParseHapi() line = %+v", line)
+		}
+	})
+
+	t.Run("Every error is reported", func(t *testing.T) {
+		src := "!!=!!H_ReportWorkerID!!=!! horey\n" +
+			"[UserStory 1 #story] !!=!! -> Task 2 #task !!=!! Actions:\n" +
+			">ACTIVE:\n" +
+			"[UserStory 1 #story] !!=!! Task 2 #task !!=!! Actions: 1\n" +
+			"[UserStory 1 #story] !!=!! -> Task 3 #task !!=!! Actions: 2, +1, fine\n" +
+			"  [Epic 1 #story] !!=!! -> Task 4 #task !!=!! Actions:\n" +
+			"[UserStory 1 #story] !!=!! -> Task 5 #task !!=!! Actions: 1, +x\n" +
+			"[UserStory 1 #story !!=!! -> Task 6 #task !!=!! Actions:\n" +
+			"[UserStory 1 #story] !!=!! -> Task 7 task !!=!! Actions:\n"
+		file, err := ParseHapi("input.hapi", []byte(src), DefaultWobjectTypes())
+
+		var errs ParseErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("ParseHapi() error = %v, want ParseErrors", err)
+		}
+		want := []string{
+			"input.hapi:2:1: expected >NEW:, >ACTIVE:, >BLOCKED: or >CLOSED: before line",
+			"input.hapi:4:28: expected '->' after parent",
+			"input.hapi:6:4: unsupported wobject type 'Epic'",
+			"input.hapi:7:62: invalid invested time '+x'",
+			"input.hapi:8:20: expected ']' after parent",
+			"input.hapi:9:42: expected '#' before title",
+		}
+		got := strings.Split(errs.Error(), "\n")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseHapi() errors =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+
+		lines := file.Workers[0].Sections[0].Lines
+		if len(lines) != 1 || lines[0].Child.Id != "3" || lines[0].Actions.Comment != "fine" {
+			t.Errorf("ParseHapi() parsed lines = %+v", lines)
+		}
+	})
+}
+
+// Report with a single line, unset values are the ones ParseHapi returns.
+func newHapiTestReport(title string, comment string, note string, leftTime int, investedTime int) []WorkerDailyReport {
+	line := WorkerWobjReport{
+		Parent:       []string{"UserStory", "1", "story " + title},
+		Child:        []string{"Task", "", title},
+		Comment:      comment,
+		LeftTime:     leftTime,
+		InvestedTime: investedTime,
+	}
+	if note != "" {
+		line.Notes = []string{note}
+	}
+	orphan := WorkerWobjReport{Parent: []string{"-1", "-1", "-1"}, Child: []string{"Task", "2", title}, Comment: note, LeftTime: investedTime, InvestedTime: leftTime}
+	return []WorkerDailyReport{
+		{WorkerID: "horey", New: []WorkerWobjReport{line}, Closed: []WorkerWobjReport{orphan}},
+		{WorkerID: "horey1", Active: []WorkerWobjReport{orphan, line}},
+	}
+}
+
+func checkHapiRoundTrip(t *testing.T, reports []WorkerDailyReport) {
+	t.Helper()
+	data, err := FormatHapi(reports)
+	if err != nil {
+		t.Fatalf("FormatHapi() error = %v", err)
+	}
+	file, err := ParseHapi("round_trip.hapi", data, DefaultWobjectTypes())
+	if err != nil {
+		t.Fatalf("ParseHapi() error = %v in\n%s", err, data)
+	}
+	got := file.WorkerDailyReports()
+	if !reflect.DeepEqual(got, reports) {
+		t.Fatalf("ParseHapi(FormatHapi()) = %+v, want %+v in\n%s", got, reports, data)
+	}
+}
+
+func TestFormatHapiRoundTrip(t *testing.T) {
+	tests := []struct {
+		name         string
+		title        string
+		comment      string
+		note         string
+		leftTime     int
+		investedTime int
+	}{
+		{name: "Zero left time", title: "task", leftTime: 0, investedTime: -1},
+		{name: "Invested time only", title: "task", comment: "done", leftTime: -1, investedTime: 2},
+		{name: "Comment starting with a digit", title: "task", comment: "3 reviews, +1 approval", leftTime: -1, investedTime: -1},
+		{name: "Comment starting with plus", title: "task", comment: "+1", leftTime: 4, investedTime: -1},
+		{name: "Title with hash", title: "C# #2 port", leftTime: 1, investedTime: 1},
+		{name: "Title with delimiter", title: "a !!=!! b !!=!!=!!", comment: "x !!=!! y", leftTime: 1, investedTime: 0},
+		{name: "Backslashes and escapes", title: `C:\temp\ \s \u{20}`, comment: `\`, note: `\n`, leftTime: -1, investedTime: -1},
+		{name: "Whitespace at the ends", title: " \tpadded\u00a0 ", comment: " \n ", note: " // note ", leftTime: -1, investedTime: -1},
+		{name: "Brackets", title: "[x] ] -> [", comment: "Actions: 1, +1", leftTime: 10, investedTime: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkHapiRoundTrip(t, newHapiTestReport(tt.title, tt.comment, tt.note, tt.leftTime, tt.investedTime))
+		})
+	}
+
+	t.Run("Written format", func(t *testing.T) {
+		data, err := FormatHapi(newHapiTestReport("a !!=!! b", "1 left", "", 0, -1)[:1])
+		if err != nil {
+			t.Fatalf("FormatHapi() error = %v", err)
+		}
+		want := "!!=!!H_ReportWorkerID!!=!! horey\n" +
+			">NEW:\n" +
+			`[UserStory 1 #story a \!!=!! b] !!=!! -> Task #a \!!=!! b !!=!! Actions: 0, \1 left` + "\n" +
+			">ACTIVE:\n>BLOCKED:\n>CLOSED:\n" +
+			`[-1 #-1] !!=!! -> Task 2 #a \!!=!! b !!=!! Actions: +0` + "\n"
+		if string(data) != want {
+			t.Errorf("FormatHapi() =\n%s\nwant\n%s", data, want)
+		}
+	})
+
+	t.Run("Invalid time", func(t *testing.T) {
+		_, err := FormatHapi(newHapiTestReport("task", "", "", -2, -1))
+		if err == nil {
+			t.Errorf("FormatHapi() with left time -2 error = nil")
+		}
+	})
+}
+
+func FuzzFormatHapi(f *testing.F) {
+	f.Add("task", "half way", "", 3, 2)
+	f.Add("C# !!=!! port", "5 left", "// note", 0, -1)
+	f.Add(` \x `, "+1, \\", "\r\n", -1, 0)
+	f.Fuzz(func(t *testing.T, title string, comment string, note string, leftTime int, investedTime int) {
+		if leftTime < -1 || investedTime < -1 {
+			t.Skip()
+		}
+		checkHapiRoundTrip(t, newHapiTestReport(title, comment, note, leftTime, investedTime))
+	})
+}
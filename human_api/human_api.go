@@ -8,18 +8,27 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/AlexeyBeley/human_api/azure_devops_api"
 )
 
 type Configuration struct {
-	SprintName                       string `json:"SprintName"`
-	ReportsDirPath                   string `json:"ReportsDirPath"`
-	WorkerId                         string `json:"WorkerId"`
-	AzureDevopsConfigurationFilePath string `json:"AzureDevopsConfigurationFilePath"`
+	SprintName                       string        `json:"SprintName"`
+	ReportsDirPath                   string        `json:"ReportsDirPath"`
+	WorkerId                         string        `json:"WorkerId"`
+	WorkerIds                        []string      `json:"WorkerIds"`
+	AzureDevopsConfigurationFilePath string        `json:"AzureDevopsConfigurationFilePath"`
+	JiraConfigurationFilePath        string        `json:"JiraConfigurationFilePath"`
+	GithubConfigurationFilePath      string        `json:"GithubConfigurationFilePath"`
+	LocalStoreFilePath               string        `json:"LocalStoreFilePath"`
+	Tracker                          string        `json:"Tracker"`
+	WobjectTypes                     WobjectTypes  `json:"WobjectTypes"`
+	DiscoverWobjectTypes             bool          `json:"DiscoverWobjectTypes"`
+	StateProcess                     string        `json:"StateProcess"`
+	StateMappings                    StateMappings `json:"StateMappings"`
 }
 
 type Wobject struct {
@@ -35,6 +44,11 @@ type Wobject struct {
 	Status       string    `json:"Status"`
 	Sprint       string    `json:"Sprint"`
 	Type         string    `json:"Type"`
+	Rev          int       `json:"Rev,omitempty"`
+	State        string    `json:"State,omitempty"`
+	Tags         string    `json:"Tags,omitempty"`
+	// Day the Description was reported on, the date of the daily directory.
+	ReportDate time.Time `json:"-"`
 }
 
 const preReportFileName = "pre_report.json"
@@ -42,31 +56,11 @@ const inputFileName = "input.hapi"
 const baseFileName = "base.hapi"
 const postReportFileName = "post_report.json"
 
-func check(e error) {
-	if e != nil {
-		strErr := fmt.Sprintf("%v", e)
-		data := []byte(strErr)
-		err := os.WriteFile("/tmp/hapi.log", data, 0644) // 0644 are file permissions
-		if err != nil {
-			fmt.Println("Error writing to file:", err)
-			return
-		}
-		panic(e)
-	}
-}
+// Daily directories are named by their date.
+const dailyDirDateFormat = "2006_01_02"
 
-func check_ng(prefixData string, e error) {
-	if e != nil {
-		strErr := fmt.Sprintf("%s, %v", prefixData, e)
-		data := []byte(strErr)
-		err := os.WriteFile("/tmp/hapi.log", data, 0644) // 0644 are file permissions
-		if err != nil {
-			fmt.Println("Error writing to file:", err)
-			return
-		}
-		panic(fmt.Errorf("%s: %v", prefixData, e))
-	}
-}
+// WorkerIds entry selecting every worker of the sprint.
+const allWorkersID = "*"
 
 func DailyRoutine(configFilePath string) error {
 	/*
@@ -87,68 +81,245 @@ func DailyRoutine(configFilePath string) error {
 	}
 	fmt.Println("Loaded config")
 
-	now := time.Now()
-	dateDirName := now.Format("2006_01_02")
-
-	dateDirPath := filepath.Join(config.ReportsDirPath, config.SprintName, dateDirName)
+	dateDirPath := getDailyDirPath(config)
 	fmt.Println("Generated new directory path: " + dateDirPath)
 
 	curDir, err := os.Getwd()
-	check(err)
+	if err != nil {
+		return err
+	}
 	fmt.Printf("Current workind dir: %v\n", curDir)
 
-	dateDirFullPath := filepath.Join(config.ReportsDirPath, config.SprintName, dateDirName)
-	err = os.MkdirAll(dateDirFullPath, 0755)
+	err = os.MkdirAll(dateDirPath, 0755)
 	if err != nil {
 		fmt.Printf("was not able to create '%v'\n", dateDirPath)
 		return err
 	}
 
-	fmt.Println("Created new directory path: " + dateDirFullPath)
+	fmt.Println("Created new directory path: " + dateDirPath)
+
+	files := newDailyFiles(dateDirPath)
+	state, err := LoadDailyState(files)
+	if err != nil {
+		return err
+	}
+	err = state.Validate(files)
+	if err != nil {
+		return err
+	}
+	if state.Phase == PhaseDone {
+		fmt.Printf("Daily routine already done: %v\n", files.PostReport)
+		return nil
+	}
+
+	tracker, err := NewTracker(config)
+	if err != nil {
+		return err
+	}
+	err = discoverWobjectTypes(&config, tracker)
+	if err != nil {
+		return err
+	}
+	return runDailyPhases(config, tracker, files, state)
+}
+
+// Run the daily routine from the current phase. Extract stops in the editing
+// phase for the user to edit input.hapi, the next run submits it.
+func runDailyPhases(config Configuration, tracker Tracker, files dailyFiles, state *DailyState) error {
+	switch state.Phase {
+	case PhaseEmpty:
+		err := tracker.Download(files.PreReport)
+		if err != nil {
+			return &TrackerError{Op: "download", Err: err}
+		}
+		err = state.Transition(PhaseDownloaded)
+		if err != nil {
+			return err
+		}
+		fallthrough
+	case PhaseDownloaded:
+		err := DailyRoutineExtract(config, tracker, files.PreReport, files.Input, files.Base, files.PostReport)
+		if err != nil {
+			return err
+		}
+		return state.Transition(PhaseEditing)
+	case PhaseEditing, PhaseSubmitting:
+		// Submitting once the submit changed the tracker, a submit failing before
+		// that, on a conflict for example, stays in editing.
+		startSubmitting := func() error {
+			if state.Phase != PhaseEditing {
+				return nil
+			}
+			return state.Transition(PhaseSubmitting)
+		}
+		err := dailyRoutineSubmit(config, tracker, files.Input, files.Base, files.PostReport, startSubmitting)
+		if err != nil {
+			return err
+		}
+		err = startSubmitting()
+		if err != nil {
+			return err
+		}
+		return state.Transition(PhaseDone)
+	}
+	return fmt.Errorf("daily phase '%s' has no next step", state.Phase)
+}
+
+// Directory of today's daily routine files.
+func getDailyDirPath(config Configuration) string {
+	return filepath.Join(config.ReportsDirPath, config.SprintName, time.Now().Format(dailyDirDateFormat))
+}
 
-	preReportFilePath := filepath.Join(dateDirFullPath, preReportFileName)
-	inputFilePath := filepath.Join(dateDirFullPath, inputFileName)
-	baseFilePath := filepath.Join(dateDirFullPath, baseFileName)
-	postReportFilePath := filepath.Join(dateDirFullPath, postReportFileName)
+// Plan what the daily routine would submit today. Nothing is sent to the tracker.
+func DailyRoutinePlan(configFilePath string) (*Plan, error) {
+	config, err := loadConfiguration(configFilePath)
+	if err != nil {
+		return nil, err
+	}
 
-	if _, err := os.Stat(postReportFilePath); err == nil {
-		return fmt.Errorf("post report file exists. The routine finished: %v", dateDirFullPath)
+	files := newDailyFiles(getDailyDirPath(config))
+	inputExists, err := checkFileExists(files.Input)
+	if err != nil {
+		return nil, err
+	}
+	baseExists, err := checkFileExists(files.Base)
+	if err != nil {
+		return nil, err
+	}
+	if !inputExists || !baseExists {
+		return nil, fmt.Errorf("nothing to plan, run the daily routine to generate '%s' first", files.Input)
 	}
+	if config.DiscoverWobjectTypes {
+		tracker, err := NewTracker(config)
+		if err != nil {
+			return nil, err
+		}
+		err = discoverWobjectTypes(&config, tracker)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return GenerateSubmitPlan(config, files.Input, files.Base)
+}
 
-	azure_devops_config, err := azure_devops_api.LoadConfig(config.AzureDevopsConfigurationFilePath)
+// Describe the phase of today's daily directory, its next step and how to recover.
+func DailyRoutineStatus(configFilePath string) (DailyStatus, error) {
+	config, err := loadConfiguration(configFilePath)
+	if err != nil {
+		return DailyStatus{}, err
+	}
+	return GetDailyStatus(newDailyFiles(getDailyDirPath(config)))
+}
+
+// Reset today's daily directory to an earlier phase.
+func DailyRoutineReset(configFilePath string, phase string) error {
+	config, err := loadConfiguration(configFilePath)
 	if err != nil {
 		return err
 	}
-	log.Printf("inputFilePath: %v\n", inputFilePath)
-	if !checkFileExists(inputFilePath) {
-		return DailyRoutineExtract(config, azure_devops_config, preReportFilePath, inputFilePath, baseFilePath, postReportFilePath)
+	return ResetDailyPhase(newDailyFiles(getDailyDirPath(config)), phase)
+}
+
+// Download the tracker again and regenerate base.hapi, keeping the lines the
+// user already edited in input.hapi. The previous input is kept as input.hapi.bak.
+func DailyRoutineRedownload(configFilePath string) error {
+	config, err := loadConfiguration(configFilePath)
+	if err != nil {
+		return err
+	}
+	files := newDailyFiles(getDailyDirPath(config))
+	state, err := LoadDailyState(files)
+	if err != nil {
+		return err
 	}
-	if !checkFileExists(preReportFilePath) ||
-		!checkFileExists(inputFilePath) ||
-		!checkFileExists(baseFilePath) ||
-		checkFileExists(postReportFilePath) {
-		return fmt.Errorf("undefined status: %s", postReportFilePath)
+	if state.Phase != PhaseEditing {
+		return fmt.Errorf("can redownload in the '%s' phase only, the phase is '%s'", PhaseEditing, state.Phase)
+	}
+	err = state.Validate(files)
+	if err != nil {
+		return err
+	}
+	tracker, err := NewTracker(config)
+	if err != nil {
+		return err
+	}
+	err = discoverWobjectTypes(&config, tracker)
+	if err != nil {
+		return err
+	}
+
+	oldBase, err := ReadDailyFromHRFile(files.Base, config.wobjectTypes())
+	if err != nil {
+		return err
+	}
+	oldInput, err := ReadDailyFromHRFile(files.Input, config.wobjectTypes())
+	if err != nil {
+		return err
+	}
+	err = copyFile(files.Input, files.Input+".bak")
+	if err != nil {
+		return err
 	}
-	return DailyRoutineSubmit(azure_devops_config, inputFilePath, baseFilePath, postReportFilePath)
 
+	err = tracker.Download(files.PreReport)
+	if err != nil {
+		return &TrackerError{Op: "download", Err: err}
+	}
+	err = GenerateDailyReport(config, tracker, files.PreReport, files.Base)
+	if err != nil {
+		return err
+	}
+	newBase, err := ReadDailyFromHRFile(files.Base, config.wobjectTypes())
+	if err != nil {
+		return err
+	}
+
+	_, err = WriteDailyToHRFile(CarryOverReportEdits(oldBase, oldInput, newBase), files.Input)
+	if err != nil {
+		return err
+	}
+	return state.Transition(PhaseEditing)
 }
 
-func DailyRoutineExtract(config Configuration, azureDevopsConfig azure_devops_api.Configuration, preReportFilePath, inputFilePath, baseFilePath, postReportFilePath string) (err error) {
-	if !checkFileExists(preReportFilePath) {
-		if checkFileExists(inputFilePath) {
+func DailyRoutineExtract(config Configuration, tracker Tracker, preReportFilePath, inputFilePath, baseFilePath, postReportFilePath string) (err error) {
+	preReportExists, err := checkFileExists(preReportFilePath)
+	if err != nil {
+		return err
+	}
+	inputExists, err := checkFileExists(inputFilePath)
+	if err != nil {
+		return err
+	}
+	baseExists, err := checkFileExists(baseFilePath)
+	if err != nil {
+		return err
+	}
+
+	if !preReportExists {
+		if inputExists {
 			return fmt.Errorf("pre report file does not exist. Input file exists '%v'", inputFilePath)
 		}
-		if checkFileExists(baseFilePath) {
+		if baseExists {
 			return fmt.Errorf("pre report file does not exist. Base file exists '%v'", baseFilePath)
 		}
-		DownloadAllWits(azureDevopsConfig, preReportFilePath)
+		err = tracker.Download(preReportFilePath)
+		if err != nil {
+			return &TrackerError{Op: "download", Err: err}
+		}
 	}
 
-	if !checkFileExists(inputFilePath) {
+	if !inputExists {
 
-		GenerateDailyReport(config, preReportFilePath, baseFilePath)
+		err = GenerateDailyReport(config, tracker, preReportFilePath, baseFilePath)
+		if err != nil {
+			return err
+		}
+		err = PrefillDailyReportFromPreviousDay(baseFilePath, config.wobjectTypes())
+		if err != nil {
+			return err
+		}
 		//_, err = ConvertDailyJsonToHR(dailyJSONFilePath, baseFilePath)
-		//check(err)
 
 		err = copyFile(baseFilePath, inputFilePath)
 		if err != nil {
@@ -156,7 +327,7 @@ func DailyRoutineExtract(config Configuration, azureDevopsConfig azure_devops_ap
 			return err
 		}
 		return nil
-	} else if checkFileExists(baseFilePath) {
+	} else if baseExists {
 		return fmt.Errorf("input file does not exist. Base file exists '%v'", baseFilePath)
 	}
 
@@ -172,23 +343,88 @@ func DailyRoutineExtract(config Configuration, azureDevopsConfig azure_devops_ap
 	return nil
 }
 
-func GenerateDailyReport(config Configuration, statusFilePath string, dstFilePath string) {
-	wobjects, err := ConvertAzureDevopsStatusToWobjects(statusFilePath)
-	check(err)
-	GenerateDailyReportFromWobjects(config, wobjects, dstFilePath)
-	//WorkerDailyReport{}
+// Pre-fill the base report with the comments and ordering of the latest earlier
+// date directory of the sprint. Nothing is done when there is none.
+func PrefillDailyReportFromPreviousDay(baseFilePath string, types WobjectTypes) error {
+	dateDirPath := filepath.Dir(baseFilePath)
+	previousDirPath, ok, err := findPreviousDailyDirPath(dateDirPath)
+	if err != nil || !ok {
+		return err
+	}
+	previousFiles := newDailyFiles(previousDirPath)
+	log.Printf("pre-filling '%s' from '%s'\n", baseFilePath, previousFiles.Input)
+
+	previousBase, err := ReadDailyFromHRFile(previousFiles.Base, types)
+	if err != nil {
+		return err
+	}
+	previousInput, err := ReadDailyFromHRFile(previousFiles.Input, types)
+	if err != nil {
+		return err
+	}
+	base, err := ReadDailyFromHRFile(baseFilePath, types)
+	if err != nil {
+		return err
+	}
+
+	_, err = WriteDailyToHRFile(PrefillReportsFromPreviousDay(previousBase, previousInput, base), baseFilePath)
+	return err
+}
+
+// Latest sibling date directory before dateDirPath with a base and an input report.
+func findPreviousDailyDirPath(dateDirPath string) (previousDirPath string, ok bool, err error) {
+	entries, err := os.ReadDir(filepath.Dir(dateDirPath))
+	if err != nil {
+		return "", false, err
+	}
+
+	dateDirName := filepath.Base(dateDirPath)
+	previousDirName := ""
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() >= dateDirName || entry.Name() <= previousDirName {
+			continue
+		}
+		if _, err := time.Parse(dailyDirDateFormat, entry.Name()); err != nil {
+			continue
+		}
+		files := newDailyFiles(filepath.Join(filepath.Dir(dateDirPath), entry.Name()))
+		baseExists, err := checkFileExists(files.Base)
+		if err != nil {
+			return "", false, err
+		}
+		inputExists, err := checkFileExists(files.Input)
+		if err != nil {
+			return "", false, err
+		}
+		if baseExists && inputExists {
+			previousDirName = entry.Name()
+		}
+	}
+	if previousDirName == "" {
+		return "", false, nil
+	}
+	return filepath.Join(filepath.Dir(dateDirPath), previousDirName), true, nil
+}
+
+func GenerateDailyReport(config Configuration, tracker Tracker, statusFilePath string, dstFilePath string) error {
+	wobjects, err := readTrackerWobjects(tracker, statusFilePath)
+	if err != nil {
+		return err
+	}
+	_, err = GenerateDailyReportFromWobjects(config, wobjects, dstFilePath)
+	return err
 }
 
-func GenerateDailyReportFromWobjects(config Configuration, wobjects map[string]*Wobject, dstFilePath string) (reportFilePath string) {
+func GenerateDailyReportFromWobjects(config Configuration, wobjects map[string]*Wobject, dstFilePath string) (reportFilePath string, err error) {
 	log.Printf("filtering relevant wobkjects: %v\n", len(wobjects))
 	wobjectsRelevant := FilterRelevantDailyReportWobjects(config, wobjects)
-	new := []WorkerWobjReport{}
-	active := []WorkerWobjReport{}
-	blocked := []WorkerWobjReport{}
-	closed := []WorkerWobjReport{}
 
-	reports := []WorkerDailyReport{}
-	var workerID string
+	reportsByWorkerID := make(map[string]*WorkerDailyReport)
+	for _, workerID := range GetReportWorkerIDs(config) {
+		reportsByWorkerID[workerID] = &WorkerDailyReport{WorkerID: workerID}
+	}
+
+	newCount := 0
 	for wobjid, wobject := range wobjectsRelevant {
 		if wobjid == "-1" {
 			continue
@@ -201,48 +437,93 @@ func GenerateDailyReportFromWobjects(config Configuration, wobjects map[string]*
 			continue
 		}
 
-		parentPointer, childPointer = GenerateParentAndChildFromParentlessWobject(wobject, wobjectsRelevant)
+		parentPointer, childPointer, err = GenerateParentAndChildFromParentlessWobject(config.wobjectTypes(), wobject, wobjectsRelevant)
+		if err != nil {
+			return reportFilePath, err
+		}
 
-		workerID = wobject.WorkerID
+		workerDailyReport, ok := reportsByWorkerID[wobject.WorkerID]
+		if !ok {
+			workerDailyReport = &WorkerDailyReport{WorkerID: wobject.WorkerID}
+			reportsByWorkerID[wobject.WorkerID] = workerDailyReport
+		}
 
 		report := WorkerWobjReport{Parent: []string{parentPointer.Type, parentPointer.Id, parentPointer.Title},
-			Child: []string{childPointer.Type, childPointer.Id, childPointer.Title}}
+			Child: []string{childPointer.Type, childPointer.Id, childPointer.Title}, LeftTime: -1, InvestedTime: -1}
 		switch wobject.Status {
 		case "New":
-			new = append(new, report)
+			workerDailyReport.New = append(workerDailyReport.New, report)
+			newCount++
 		case "Closed":
-			closed = append(closed, report)
+			workerDailyReport.Closed = append(workerDailyReport.Closed, report)
 		case "Active":
-			active = append(active, report)
+			workerDailyReport.Active = append(workerDailyReport.Active, report)
 		case "Blocked":
-			blocked = append(blocked, report)
+			workerDailyReport.Blocked = append(workerDailyReport.Blocked, report)
 		default:
-			check(fmt.Errorf("invalid wobject.Status: %v", wobject.Status))
+			return reportFilePath, newValidationError("[%s][%s] - invalid wobject.Status: %v", wobject.Id, wobject.Title, wobject.Status)
+		}
+	}
+
+	if newCount == 0 {
+		return reportFilePath, newValidationError("new wobjects are empty: %v", newCount)
+	}
+
+	// Configured workers keep their order, workers found in the sprint follow sorted.
+	workerIDs := GetReportWorkerIDs(config)
+	foundWorkerIDs := []string{}
+	for workerID := range reportsByWorkerID {
+		if !slices.Contains(workerIDs, workerID) {
+			foundWorkerIDs = append(foundWorkerIDs, workerID)
 		}
 	}
+	sort.Strings(foundWorkerIDs)
+	workerIDs = append(workerIDs, foundWorkerIDs...)
+
+	reports := []WorkerDailyReport{}
+	for _, workerID := range workerIDs {
+		reports = append(reports, *reportsByWorkerID[workerID])
+	}
+	_, err = WriteDailyToHRFile(reports, dstFilePath)
+	if err != nil {
+		return reportFilePath, err
+	}
+	return dstFilePath, nil
+}
+
+// Return the explicitly configured report workers: WorkerIds in team mode, otherwise WorkerId.
+// The "*" entry is not a worker, it makes every worker of the sprint relevant.
+func GetReportWorkerIDs(config Configuration) []string {
+	if len(config.WorkerIds) == 0 {
+		return []string{config.WorkerId}
+	}
 
-	if len(new) == 0 {
-		check(fmt.Errorf("new wobjects are empty: %v", new))
+	workerIDs := []string{}
+	for _, workerID := range config.WorkerIds {
+		if workerID != allWorkersID {
+			workerIDs = append(workerIDs, workerID)
+		}
 	}
+	return workerIDs
+}
 
-	workerDailyReport := WorkerDailyReport{WorkerID: workerID,
-		New:     new,
-		Active:  active,
-		Blocked: blocked,
-		Closed:  closed,
+func IsReportWorker(config Configuration, workerID string) bool {
+	if slices.Contains(config.WorkerIds, allWorkersID) {
+		return true
 	}
-	reports = append(reports, workerDailyReport)
-	WriteDailyToHRFile(reports, dstFilePath)
-	return reportFilePath
+	return slices.Contains(GetReportWorkerIDs(config), workerID)
 }
 
 // Generate Parent and child for Wobject that has not explicit parent.
 // The wobject can become either Parent from new qobject or a Child with undefind (-1) Parent
-func GenerateParentAndChildFromParentlessWobject(wobject *Wobject, wobjectsRelevant map[string]*Wobject) (parent, child *Wobject) {
-	if wobject.Type == "Task" || wobject.Type == "Bug" {
+func GenerateParentAndChildFromParentlessWobject(types WobjectTypes, wobject *Wobject, wobjectsRelevant map[string]*Wobject) (parent, child *Wobject, err error) {
+	if types.IsLeaf(wobject.Type) {
 		if wobject.ParentID == "" {
 			wobject.ParentID = "-1"
 		}
+		if _, ok := wobjectsRelevant[wobject.ParentID]; !ok {
+			return nil, nil, fmt.Errorf("[%s][%s] - parent '%s' is missing in the downloaded work items", wobject.Id, wobject.Title, wobject.ParentID)
+		}
 		if wobjectsRelevant[wobject.ParentID].ChildrenIDs == nil {
 			wobjectsRelevant[wobject.ParentID].ChildrenIDs = new([]string)
 		}
@@ -255,7 +536,7 @@ func GenerateParentAndChildFromParentlessWobject(wobject *Wobject, wobjectsRelev
 		parent = wobject
 	}
 
-	return parent, child
+	return parent, child, nil
 }
 
 func FilterRelevantDailyReportWobjects(config Configuration, wobjects map[string]*Wobject) map[string]*Wobject {
@@ -268,7 +549,7 @@ func FilterRelevantDailyReportWobjects(config Configuration, wobjects map[string
 	}
 
 	for _, wobject := range wobjects {
-		if wobject.WorkerID != config.WorkerId {
+		if !IsReportWorker(config, wobject.WorkerID) {
 			continue
 		}
 		if wobject.Sprint != config.SprintName {
@@ -289,134 +570,82 @@ func FilterRelevantDailyReportWobjects(config Configuration, wobjects map[string
 	return wobjectsRelevantById
 }
 
-func ConvertAzureDevopsStatusToWobjects(filePath string) (wobjects map[string]*Wobject, err error) {
-	wits, err := azure_devops_api.ReadWitsFromFile(filePath)
-	wobjects = make(map[string]*Wobject)
-
-	check(err)
-	//log.Printf("todo: %v\n", wits)
-	for _, wit := range wits {
-		wobject, err := ConvertWitToWobject(wit)
-		check(err)
-		wobjects[wobject.Id] = &wobject
-	}
-	for wobjId, wobject := range wobjects {
-		if wobject.ParentID != "" && wobject.ParentID != "-1" {
-			parent, ok := wobjects[wobject.ParentID]
-			if !ok {
-				continue
-			}
-			*(parent.ChildrenIDs) = append(*(parent.ChildrenIDs), wobjId)
-		}
-	}
-	return wobjects, nil
-}
-
-func ConvertWitToWobject(wit azure_devops_api.WorkItem) (wobject Wobject, err error) {
-	wobject.ParentID = extractFloat64String(wit, "System.Parent")
-	wobject.Id = strconv.Itoa(wit.ID)
-	wobject.Title = wit.Fields["System.Title"].(string)
-	wobject.Priority = extractFloat64Int(wit, "Microsoft.VSTS.Common.Priority")
-
-	wobject.WorkerID = extractWorkerID(wit)
-	wobject.ChildrenIDs = &[]string{}
-
-	wobject.Status = extractStatus(wit)
-	SprintParts := strings.Split(wit.Fields["System.IterationPath"].(string), "\\")
-	wobject.Sprint = SprintParts[len(SprintParts)-1]
-	wobject.Type = strings.Replace(wit.Fields["System.WorkItemType"].(string), " ", "", -1)
-	return wobject, nil
+func DailyRoutineSubmit(config Configuration, tracker Tracker, inputFilePath, baseFilePath, postReportFilePath string) (err error) {
+	return dailyRoutineSubmit(config, tracker, inputFilePath, baseFilePath, postReportFilePath, nil)
 }
 
-func extractStatus(workItem azure_devops_api.WorkItem) string {
-	SystemState := workItem.Fields["System.State"].(string)
-	switch SystemState {
-	case "New":
-		return "New"
-	case "Closed":
-		return "Closed"
-	case "Resolved":
-		return "Closed"
-	case "Removed":
-		return "Closed"
-	case "Active":
-		return "Active"
-	case "Blocked":
-		return "Blocked"
-	default:
-		log.Printf("invalid State: %v, using default\n", SystemState)
-		return "Blocked"
+// onChange is called every time the journal records a tracker change.
+func dailyRoutineSubmit(config Configuration, tracker Tracker, inputFilePath, baseFilePath, postReportFilePath string, onChange func() error) (err error) {
+	plan, err := GenerateSubmitPlan(config, inputFilePath, baseFilePath)
+	if err != nil {
+		return err
 	}
-}
 
-func extractWorkerID(workItem azure_devops_api.WorkItem) string {
-	var data string
-	if workItem.Fields["System.AssignedTo"] != nil {
-		data = workItem.Fields["System.AssignedTo"].(map[string]interface{})["uniqueName"].(string)
-	} else {
-		data = workItem.Fields["System.CreatedBy"].(map[string]interface{})["uniqueName"].(string)
+	journal, err := LoadJournal(filepath.Join(filepath.Dir(inputFilePath), journalFileName))
+	if err != nil {
+		return err
 	}
+	journal.OnChange = onChange
 
-	return strings.Split(data, "@")[0]
-}
+	err = MergeRemoteChanges(tracker, plan, journal, filepath.Join(filepath.Dir(inputFilePath), preReportFileName))
+	if err != nil {
+		return err
+	}
 
-func extractFloat64Int(workItem azure_devops_api.WorkItem, FieldKey string) int {
-	var retVal int
-	if workItem.Fields[FieldKey] == nil {
-		return retVal
+	startedAt := time.Now()
+	err = ApplyPlan(tracker, plan, journal)
+	if err != nil {
+		return err
 	}
 
-	value, ok := workItem.Fields[FieldKey]
-	if !ok {
-		check(fmt.Errorf("extractFloat64Int: Was not able to Extract %v, %v, %v", FieldKey, value, workItem))
+	report, err := GeneratePostReport(tracker, plan, journal, startedAt, postReportFilePath)
+	if err != nil {
+		return err
 	}
-	retVal, err := strconv.Atoi(strconv.FormatFloat(value.(float64), 'f', 0, 64))
-	check(err)
-	return retVal
+	return WritePostReport(report, postReportFilePath)
 }
 
-func extractFloat64String(workItem azure_devops_api.WorkItem, FieldKey string) string {
-	var retVal string
-	if workItem.Fields[FieldKey] == nil {
-		return retVal
+// Plan the tracker changes made by the user in the input file, without submitting them.
+func GenerateSubmitPlan(config Configuration, inputFilePath, baseFilePath string) (*Plan, error) {
+	inputWobjects, err := GetWobjectsFromReportFile(config, inputFilePath)
+	if err != nil {
+		return nil, err
 	}
-
-	value, ok := workItem.Fields[FieldKey]
-	if !ok {
-		check(fmt.Errorf("extractFloat64String: Was not able to Extract %v, %v, %v", FieldKey, value, workItem))
+	if reportDate, err := time.Parse(dailyDirDateFormat, filepath.Base(filepath.Dir(inputFilePath))); err == nil {
+		for _, wobject := range inputWobjects {
+			wobject.ReportDate = reportDate
+		}
+	}
+	baseWobjects, err := GetWobjectsFromReportFile(config, baseFilePath)
+	if err != nil {
+		return nil, err
 	}
-	retValtmp, err := strconv.Atoi(strconv.FormatFloat(value.(float64), 'f', 0, 64))
-	check(err)
-	retVal = strconv.Itoa(retValtmp)
-	return retVal
-}
-
-func DailyRoutineSubmit(config azure_devops_api.Configuration, inputFilePath, baseFilePath, postReportFilePath string) (err error) {
-	inputWobjects := GetWobjectsFromReportFile(config, inputFilePath)
-	baseWobjects := GetWobjectsFromReportFile(config, baseFilePath)
 
 	err = CleanWobjectsUserInput(inputWobjects)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = ValidateWobjectsUserInput(baseWobjects, inputWobjects)
+	err = ValidateWobjectsUserInput(config.wobjectTypes(), baseWobjects, inputWobjects)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	wobjects := FilterChangedWobjects(baseWobjects, inputWobjects)
+	wobjects, err := FilterChangedWobjects(baseWobjects, inputWobjects)
+	if err != nil {
+		return nil, err
+	}
 
-	requestDicts := GenerateDictsFromWobjects(wobjects)
-	err = azure_devops_api.SubmitSprintStatus(config, requestDicts)
-	return err
+	return GeneratePlan(baseWobjects, wobjects)
 }
 
-func GetWobjectsFromReportFile(config azure_devops_api.Configuration, filePath string) map[string]*Wobject {
-	inputJsonFilePath := strings.Replace(filepath.Base(filePath), ".hapi", "_hapi.json", 1)
+func GetWobjectsFromReportFile(config Configuration, filePath string) (map[string]*Wobject, error) {
+	inputJsonFilePath := filepath.Join(filepath.Dir(filePath), strings.Replace(filepath.Base(filePath), ".hapi", "_hapi.json", 1))
 
-	reports, err := ConvertHRToDailyJson(filePath, inputJsonFilePath)
-	check(err)
+	reports, err := ConvertHRToDailyJson(filePath, inputJsonFilePath, config.wobjectTypes())
+	if err != nil {
+		return nil, err
+	}
 
 	return GenerateWobjectsFromDailyReports(config, reports)
 
@@ -433,7 +662,7 @@ func CleanWobjectsUserInput(inputWobjects map[string]*Wobject) error {
 	return nil
 }
 
-func ValidateWobjectsUserInput(baseById map[string]*Wobject, inputWobjects map[string]*Wobject) error {
+func ValidateWobjectsUserInput(types WobjectTypes, baseById map[string]*Wobject, inputWobjects map[string]*Wobject) error {
 	errors := []string{}
 	for _, wobject := range inputWobjects {
 
@@ -464,27 +693,33 @@ func ValidateWobjectsUserInput(baseById map[string]*Wobject, inputWobjects map[s
 				errors = append(errors, fmt.Sprintf("wobject Id '%s' from input does not exist in base file", wobject.Id))
 			}
 		}
-		errors = append(errors, ValidateWobjectUserInput(wobject)...)
+		errors = append(errors, ValidateWobjectUserInput(types, wobject)...)
+
+		if parent, ok := inputWobjects[wobject.ParentID]; ok && wobject.Id != "-1" && parent.Id != "-1" {
+			if err := types.CheckParent(wobject.Type, parent.Type); err != nil {
+				errors = append(errors, fmt.Sprintf("[%s][%s] - %v", wobject.Id, wobject.Title, err))
+			}
+		}
 
 		if wobject.Id == "-1" && len(*wobject.ChildrenIDs) == 0 {
 			errors = append(errors, fmt.Sprintf("child wobject is -1 in input.json. You forgot to fill it: %v", wobject))
 		}
 	}
 	if len(errors) > 0 {
-		return fmt.Errorf("input Validation errors:\n %v", strings.Join(errors, "\n"))
+		return &ValidationError{Errors: errors}
 	}
 	return nil
 }
 
-func ValidateWobjectUserInput(wobject *Wobject) (errors []string) {
+func ValidateWobjectUserInput(types WobjectTypes, wobject *Wobject) (errors []string) {
 	//new task/bug wobject
 	if wobject.Id == "-1" {
 		return errors
 	}
 
 	if len(*wobject.ChildrenIDs) == 0 {
-		if wobject.Type != "Task" && wobject.Type != "Bug" {
-			errors = append(errors, fmt.Sprintf("[%s][%s] - unsupported Wobject Type %s. Use one of ['Task', 'Bug']", wobject.Id, wobject.Title, wobject.Type))
+		if !types.IsLeaf(wobject.Type) {
+			errors = append(errors, fmt.Sprintf("[%s][%s] - unsupported Wobject Type %s. Use one of %v", wobject.Id, wobject.Title, wobject.Type, types.Names(true)))
 		}
 
 		//new Task/Bug
@@ -507,14 +742,14 @@ func ValidateWobjectUserInput(wobject *Wobject) (errors []string) {
 	return errors
 }
 
-func FilterChangedWobjects(baseById map[string]*Wobject, inputWobjects map[string]*Wobject) (wobjectsRet []*Wobject) {
+func FilterChangedWobjects(baseById map[string]*Wobject, inputWobjects map[string]*Wobject) (wobjectsRet []*Wobject, err error) {
 	for _, inputWobject := range inputWobjects {
 		if len(*inputWobject.ChildrenIDs) == 0 && inputWobject.Id == "-1" {
-			check(fmt.Errorf("can not submit unfiled child wobject: %v", inputWobject))
+			return nil, newValidationError("can not submit unfiled child wobject: %v", inputWobject)
 		}
 
 		if inputWobject.Id == "" {
-			check(fmt.Errorf("filterning failed on empty wobject Id : %v", inputWobject))
+			return nil, newValidationError("filterning failed on empty wobject Id : %v", inputWobject)
 		}
 
 		//New wobject
@@ -525,8 +760,7 @@ func FilterChangedWobjects(baseById map[string]*Wobject, inputWobjects map[strin
 
 		baseWobject, ok := baseById[inputWobject.Id]
 		if !ok {
-			check(fmt.Errorf("input Wobject ID '%v' does not exist in base.haphi ", inputWobject.Id))
-			continue
+			return nil, newValidationError("input Wobject ID '%v' does not exist in base.haphi ", inputWobject.Id)
 		}
 
 		if inputWobject.Description == baseWobject.Description &&
@@ -537,33 +771,42 @@ func FilterChangedWobjects(baseById map[string]*Wobject, inputWobjects map[strin
 		}
 		wobjectsRet = append(wobjectsRet, inputWobject)
 	}
-	return wobjectsRet
+	return wobjectsRet, nil
 }
 
-func GenerateWobjectsFromDailyReports(cofig azure_devops_api.Configuration, reports []WorkerDailyReport) map[string]*Wobject {
+func GenerateWobjectsFromDailyReports(config Configuration, reports []WorkerDailyReport) (map[string]*Wobject, error) {
 	wobjectById := make(map[string]*Wobject)
+	errors := []string{}
 	for _, report := range reports {
-		for _, wobjectReport := range report.New {
-			GenerateWobjectsFromWobjectReport(cofig, wobjectById, report.WorkerID, "New", wobjectReport)
-		}
-
-		for _, wobjectReport := range report.Active {
-			GenerateWobjectsFromWobjectReport(cofig, wobjectById, report.WorkerID, "Active", wobjectReport)
-		}
-
-		for _, wobjectReport := range report.Blocked {
-			GenerateWobjectsFromWobjectReport(cofig, wobjectById, report.WorkerID, "Blocked", wobjectReport)
-		}
-		for _, wobjectReport := range report.Closed {
-			GenerateWobjectsFromWobjectReport(cofig, wobjectById, report.WorkerID, "Closed", wobjectReport)
+		statusReports := []struct {
+			status         string
+			wobjectReports []WorkerWobjReport
+		}{
+			{"New", report.New},
+			{"Active", report.Active},
+			{"Blocked", report.Blocked},
+			{"Closed", report.Closed},
+		}
+		for _, statusReport := range statusReports {
+			for _, wobjectReport := range statusReport.wobjectReports {
+				err := GenerateWobjectsFromWobjectReport(config, wobjectById, report.WorkerID, statusReport.status, wobjectReport)
+				if err != nil {
+					errors = append(errors, err.Error())
+				}
+			}
 		}
 	}
-	return wobjectById
+	if len(errors) > 0 {
+		return nil, &ValidationError{Errors: errors}
+	}
+	return wobjectById, nil
 }
 
-func GenerateWobjectsFromWobjectReport(cofig azure_devops_api.Configuration, wobjectById map[string]*Wobject, WorkerID string, status string, wobjectReport WorkerWobjReport) {
+func GenerateWobjectsFromWobjectReport(config Configuration, wobjectById map[string]*Wobject, WorkerID string, status string, wobjectReport WorkerWobjReport) error {
 	//{type, id, title}
 
+	// Parent rows give the child context only, the section is the child status.
+	// Their status is left empty, it is neither compared nor submitted.
 	if wobjectReport.Parent[1] != "-1" {
 		if _, ok := wobjectById[wobjectReport.Parent[1]]; !ok {
 			wobjParent := Wobject{Id: wobjectReport.Parent[1],
@@ -573,8 +816,7 @@ func GenerateWobjectsFromWobjectReport(cofig azure_devops_api.Configuration, wob
 				Priority:     -1,
 				InvestedTime: -1,
 				LeftTime:     -1,
-				Status:       status,
-				Sprint:       cofig.SprintName,
+				Sprint:       config.SprintName,
 				Type:         wobjectReport.Parent[0],
 				ParentID:     "-1",
 			}
@@ -586,9 +828,9 @@ func GenerateWobjectsFromWobjectReport(cofig azure_devops_api.Configuration, wob
 
 	if value, seenBefore := wobjectById[wobjectReport.Child[1]]; seenBefore {
 		if value.Id == "-1" {
-			return
+			return nil
 		}
-		check(fmt.Errorf("reported child wobject ID '%v' already appeared in a report with title %v", value.Id, value.Title))
+		return fmt.Errorf("reported child wobject ID '%v' already appeared in a report with title %v", value.Id, value.Title)
 	}
 
 	var childId string
@@ -605,7 +847,7 @@ func GenerateWobjectsFromWobjectReport(cofig azure_devops_api.Configuration, wob
 		ChildrenIDs:  &[]string{},
 		Priority:     -1,
 		Status:       status,
-		Sprint:       cofig.SprintName,
+		Sprint:       config.SprintName,
 		InvestedTime: wobjectReport.InvestedTime,
 		LeftTime:     wobjectReport.LeftTime,
 		Description:  wobjectReport.Comment,
@@ -614,44 +856,39 @@ func GenerateWobjectsFromWobjectReport(cofig azure_devops_api.Configuration, wob
 	}
 
 	wobjectById[wobj.Id] = &wobj
+	return nil
 }
 
 func GenerateDictsFromWobjects(wobjects []*Wobject) (lstRet [](*map[string]string)) {
 	for _, wobject := range wobjects {
-		dictRequest := make(map[string]string)
-
-		var err error
-
-		if !strings.HasPrefix(wobject.Id, "CreatePlease:") {
-			_, err := strconv.Atoi(wobject.Id)
-			check(err)
-		}
-
-		if !strings.HasPrefix(wobject.ParentID, "CreatePlease:") {
-			_, err = strconv.Atoi(wobject.ParentID)
-			check_ng(fmt.Sprintf("wobject [%s] [%s] ParentID:", wobject.Id, wobject.Title), err)
-		}
-
-		dictRequest["Id"] = wobject.Id
-		dictRequest["ParentID"] = wobject.ParentID
-		dictRequest["Priority"] = GuessPriorityForRequestDict(*wobject)
-		dictRequest["Title"] = wobject.Title
-		dictRequest["Description"] = wobject.Description
-		dictRequest["LeftTime"] = strconv.Itoa(wobject.LeftTime)
-		dictRequest["InvestedTime"] = strconv.Itoa(wobject.InvestedTime)
-		dictRequest["WorkerID"] = wobject.WorkerID
-		dictRequest["ChildrenIDs"] = strings.Join(*wobject.ChildrenIDs, ",")
-		dictRequest["Sprint"] = wobject.Sprint
-		dictRequest["Status"] = wobject.Status
-		dictRequest["Type"] = wobject.Type
-
+		dictRequest := GenerateDictFromWobject(wobject)
 		lstRet = append(lstRet, &dictRequest)
-
 	}
 
 	return lstRet
 }
 
+// Tracker independent, the trackers check the ids are in their format.
+func GenerateDictFromWobject(wobject *Wobject) map[string]string {
+	dictRequest := make(map[string]string)
+
+	dictRequest["Id"] = wobject.Id
+	dictRequest["ParentID"] = wobject.ParentID
+	dictRequest["Priority"] = GuessPriorityForRequestDict(*wobject)
+	dictRequest["Title"] = wobject.Title
+	dictRequest["Description"] = wobject.Description
+	dictRequest["LeftTime"] = strconv.Itoa(wobject.LeftTime)
+	dictRequest["InvestedTime"] = strconv.Itoa(wobject.InvestedTime)
+	dictRequest["WorkerID"] = wobject.WorkerID
+	dictRequest["ChildrenIDs"] = strings.Join(*wobject.ChildrenIDs, ",")
+	dictRequest["Sprint"] = wobject.Sprint
+	dictRequest["Status"] = wobject.Status
+	dictRequest["Type"] = wobject.Type
+	dictRequest["Rev"] = strconv.Itoa(wobject.Rev)
+
+	return dictRequest
+}
+
 func GuessPriorityForRequestDict(wobject Wobject) string {
 	if wobject.Priority != -1 {
 		return strconv.Itoa(wobject.Priority)
@@ -679,26 +916,22 @@ func loadConfiguration(filePath string) (config Configuration, err error) {
 		return config, err
 	}
 
+	config.WobjectTypes, err = LoadWobjectTypes(config)
+	if err != nil {
+		return config, err
+	}
 	return config, nil
 }
 
-func DownloadAllWits(config azure_devops_api.Configuration, dstFilePath string) (err error) {
-	log.Printf("downloadAllWits: %v, %v\n", config, dstFilePath)
-	err = azure_devops_api.DownloadAllWits(config, dstFilePath)
-	return err
-}
-
-// Return True if exists, False if not or fails on error.
-func checkFileExists(path string) (exists bool) {
-	_, err := os.Stat(path)
+// Return True if exists, False if not, and the error when it can not be checked.
+func checkFileExists(path string) (exists bool, err error) {
+	_, err = os.Stat(path)
 	if err == nil {
-		return true
+		return true, nil
 	} else if os.IsNotExist(err) {
-		return false
+		return false, nil
 	}
-
-	log.Fatalf("Failed checking file exists: %v", err)
-	return false
+	return false, fmt.Errorf("failed checking file exists: %v", err)
 }
 
 func copyFile(srcFilePath, dstFilePath string) error {
@@ -5,9 +5,9 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
-
-	"github.com/AlexeyBeley/human_api/azure_devops_api"
 )
 
 func TestLoadConfiguration(t *testing.T) {
@@ -48,7 +48,6 @@ func GetConfigFilePath(basename string) (string, error) {
 
 }
 
-
 func test_check(t *testing.T, err error) {
 	if err != nil {
 		t.Errorf("%v", err)
@@ -67,7 +66,7 @@ func TestGenerateDailyReportFromWobjects(t *testing.T) {
 			t.Errorf("Failed with file: %s, %v", filePath, err)
 
 		}
-		wobjects := map[string]*Wobject{"123":{
+		wobjects := map[string]*Wobject{"123": {
 			Id:           "123",
 			Title:        "Test Title",
 			Description:  "Test Description",
@@ -77,7 +76,7 @@ func TestGenerateDailyReportFromWobjects(t *testing.T) {
 			ChildrenIDs:  &[]string{"1", "2"},
 			ParentID:     "3",
 		}}
-		fileOutputPath := GenerateDailyReportFromWobjects(config, wobjects, "/tmp/base.hapi")
+		fileOutputPath, err := GenerateDailyReportFromWobjects(config, wobjects, "/tmp/base.hapi")
 		if err != nil {
 			t.Fatalf("%v", err)
 		}
@@ -88,7 +87,7 @@ func TestGenerateDailyReportFromWobjects(t *testing.T) {
 func TestConvertAzureDevopsStatusToWobjects(t *testing.T) {
 	t.Run("Init test", func(t *testing.T) {
 
-		wobjects, err := ConvertAzureDevopsStatusToWobjects("/tmp/wit.json")
+		wobjects, err := ConvertAzureDevopsStatusToWobjects("/tmp/wit.json", DefaultStateMappings())
 		test_check(t, err)
 		log.Printf("%v", wobjects)
 	})
@@ -100,12 +99,11 @@ func TestGenerateDailyReport(t *testing.T) {
 		test_check(t, err)
 		config, err := loadConfiguration(filePath)
 		test_check(t, err)
-		GenerateDailyReport(config, "/tmp/wit.json", "/tmp/base.hapi")
+		err = GenerateDailyReport(config, &AzureDevopsTracker{}, "/tmp/wit.json", "/tmp/base.hapi")
 		test_check(t, err)
 	})
 }
 
-
 func TestDailyRoutine(t *testing.T) {
 	t.Run("Init test", func(t *testing.T) {
 
@@ -122,9 +120,53 @@ func TestDailyRoutineSubmit(t *testing.T) {
 		test_check(t, err)
 		config, err := loadConfiguration(filePath)
 		test_check(t, err)
-		azure_devops_config, err := azure_devops_api.LoadConfig(config.AzureDevopsConfigurationFilePath)
+		tracker, err := NewTracker(config)
 		test_check(t, err)
-		err = DailyRoutineSubmit(azure_devops_config, "/tmp/input.hapi", "/tmp/base.hapi", "/tmp/postSubmit.json")
+		err = DailyRoutineSubmit(config, tracker, "/tmp/input.hapi", "/tmp/base.hapi", "/tmp/postSubmit.json")
 		test_check(t, err)
 	})
 }
+
+func TestGenerateDailyReportFromWobjectsTeamMode(t *testing.T) {
+	newWobjects := func() map[string]*Wobject {
+		return map[string]*Wobject{
+			"1": {Id: "1", Title: "story", WorkerID: "lead", ChildrenIDs: &[]string{"2", "3"}, ParentID: "-1", Status: "Active", Sprint: "sp1", Type: "UserStory"},
+			"2": {Id: "2", Title: "task 2", WorkerID: "horey", ChildrenIDs: &[]string{}, ParentID: "1", Status: "New", Sprint: "sp1", Type: "Task"},
+			"3": {Id: "3", Title: "task 3", WorkerID: "alice", ChildrenIDs: &[]string{}, ParentID: "1", Status: "Active", Sprint: "sp1", Type: "Task"},
+			"4": {Id: "4", Title: "task 4", WorkerID: "bob", ChildrenIDs: &[]string{}, ParentID: "-1", Status: "Closed", Sprint: "sp1", Type: "Task"},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		workerIds     []string
+		wantWorkerIDs []string
+	}{
+		{name: "Listed workers", workerIds: []string{"horey", "alice", "carol"}, wantWorkerIDs: []string{"horey", "alice", "carol"}},
+		{name: "Everyone in the sprint", workerIds: []string{"*"}, wantWorkerIDs: []string{"alice", "bob", "horey"}},
+		{name: "Listed workers and everyone", workerIds: []string{"horey", "*"}, wantWorkerIDs: []string{"horey", "alice", "bob"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			config := Configuration{SprintName: "sp1", WorkerIds: testCase.workerIds}
+			dstFilePath := filepath.Join(t.TempDir(), "base.hapi")
+			_, err := GenerateDailyReportFromWobjects(config, newWobjects(), dstFilePath)
+			if err != nil {
+				t.Fatalf("GenerateDailyReportFromWobjects() error = %v", err)
+			}
+
+			reports, err := ReadDailyFromHRFile(dstFilePath, DefaultWobjectTypes())
+			if err != nil {
+				t.Fatalf("ReadDailyFromHRFile() error = %v", err)
+			}
+			workerIDs := []string{}
+			for _, report := range reports {
+				workerIDs = append(workerIDs, strings.TrimSpace(report.WorkerID))
+			}
+			if !reflect.DeepEqual(workerIDs, testCase.wantWorkerIDs) {
+				t.Errorf("GenerateDailyReportFromWobjects() workers = %v, want %v", workerIDs, testCase.wantWorkerIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package human_api
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/AlexeyBeley/human_api/jira_api"
+)
+
+const trackerJira = "jira"
+
+// Jira issue type by Wobject type, where the names differ.
+var jiraIssueTypes = map[string]string{
+	"UserStory": "Story",
+	"Feature":   "Epic",
+}
+
+// Tracker implementation backed by jira_api.
+// Wobject ids are Jira issue keys, e.g. PROJ-12.
+type JiraTracker struct {
+	Config jira_api.Configuration
+}
+
+func NewJiraTracker(config jira_api.Configuration) *JiraTracker {
+	return &JiraTracker{Config: config}
+}
+
+func (tracker *JiraTracker) Download(dstFilePath string) error {
+	return jira_api.DownloadSprintIssues(tracker.Config, dstFilePath)
+}
+
+func (tracker *JiraTracker) DownloadWobjects(wobjectIds []string, dstFilePath string) error {
+	return jira_api.DownloadIssues(tracker.Config, wobjectIds, dstFilePath)
+}
+
+func (tracker *JiraTracker) ReadWobjects(srcFilePath string) (map[string]*Wobject, error) {
+	snapshot, err := jira_api.ReadSnapshotFromFile(srcFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertJiraSnapshotToWobjects(snapshot), nil
+}
+
+func (tracker *JiraTracker) CreateWobject(wobject *Wobject) error {
+	fields := map[string]interface{}{
+		"summary":     wobject.Title,
+		"description": wobject.Description,
+		"issuetype":   map[string]string{"name": GetJiraIssueType(wobject.Type)},
+		"assignee":    jira_api.GetAssignee(tracker.Config, wobject.WorkerID),
+	}
+	if wobject.LeftTime != -1 {
+		fields["timetracking"] = map[string]string{
+			"originalEstimate":  jira_api.FormatHours(wobject.LeftTime + max(wobject.InvestedTime, 0)),
+			"remainingEstimate": jira_api.FormatHours(wobject.LeftTime),
+		}
+	}
+
+	key, err := jira_api.CreateIssue(tracker.Config, fields)
+	if err != nil {
+		return err
+	}
+	wobject.Id = key
+
+	return tracker.submitProgress(wobject)
+}
+
+func (tracker *JiraTracker) UpdateWobject(wobject *Wobject) error {
+	fields := map[string]interface{}{
+		"summary":  wobject.Title,
+		"assignee": jira_api.GetAssignee(tracker.Config, wobject.WorkerID),
+	}
+	if wobject.LeftTime != -1 {
+		fields["timetracking"] = map[string]string{"remainingEstimate": jira_api.FormatHours(wobject.LeftTime)}
+	}
+
+	err := jira_api.UpdateIssue(tracker.Config, wobject.Id, fields)
+	if err != nil {
+		return err
+	}
+	return tracker.submitProgress(wobject)
+}
+
+func (tracker *JiraTracker) SetWobjectParent(wobject *Wobject) error {
+	return jira_api.SetIssueParent(tracker.Config, wobject.Id, wobject.ParentID)
+}
+
+// Invested time is logged as a worklog, the status is applied as a transition.
+func (tracker *JiraTracker) submitProgress(wobject *Wobject) error {
+	if wobject.InvestedTime > 0 {
+		// Unchanged comments are not logged again.
+		comment := wobject.Description
+		if comment == "-1" {
+			comment = ""
+		}
+		err := jira_api.AddWorklog(tracker.Config, wobject.Id, wobject.InvestedTime, comment)
+		if err != nil {
+			return err
+		}
+	}
+
+	if wobject.Status == "" {
+		return nil
+	}
+	return jira_api.TransitionIssue(tracker.Config, wobject.Id, wobject.Status)
+}
+
+func GetJiraIssueType(wobjectType string) string {
+	if issueType, ok := jiraIssueTypes[wobjectType]; ok {
+		return issueType
+	}
+	return wobjectType
+}
+
+func ConvertJiraSnapshotToWobjects(snapshot jira_api.Snapshot) map[string]*Wobject {
+	wobjects := make(map[string]*Wobject)
+	for _, issue := range snapshot.Issues {
+		wobject := ConvertIssueToWobject(issue)
+		wobject.Sprint = snapshot.Sprint.Name
+		wobjects[wobject.Id] = &wobject
+	}
+	for _, issue := range snapshot.Parents {
+		wobject := ConvertIssueToWobject(issue)
+		wobjects[wobject.Id] = &wobject
+	}
+
+	for wobjId, wobject := range wobjects {
+		if wobject.ParentID == "" {
+			continue
+		}
+		parent, ok := wobjects[wobject.ParentID]
+		if !ok {
+			continue
+		}
+		*(parent.ChildrenIDs) = append(*(parent.ChildrenIDs), wobjId)
+	}
+	return wobjects
+}
+
+func ConvertIssueToWobject(issue jira_api.Issue) (wobject Wobject) {
+	wobject.Id = issue.Key
+	wobject.ParentID = jira_api.GetParentKey(issue)
+	wobject.Title, _ = issue.Fields["summary"].(string)
+	wobject.ChildrenIDs = &[]string{}
+	wobject.WorkerID = extractJiraWorkerID(issue)
+	wobject.Status = extractJiraStatus(issue)
+
+	if priority, ok := issue.Fields["priority"].(map[string]interface{}); ok {
+		wobject.Priority, _ = strconv.Atoi(extractJiraString(priority, "id"))
+	}
+
+	issueType := ""
+	if issueTypeField, ok := issue.Fields["issuetype"].(map[string]interface{}); ok {
+		issueType = extractJiraString(issueTypeField, "name")
+	}
+	wobject.Type = strings.Replace(issueType, " ", "", -1)
+	for wobjectType, jiraIssueType := range jiraIssueTypes {
+		if jiraIssueType == issueType {
+			wobject.Type = wobjectType
+		}
+	}
+	if wobject.Type == "Sub-task" || wobject.Type == "Subtask" {
+		wobject.Type = "Task"
+	}
+	return wobject
+}
+
+// Jira statuses are mapped by their category, "Blocked" is matched by name.
+func extractJiraStatus(issue jira_api.Issue) string {
+	status, ok := issue.Fields["status"].(map[string]interface{})
+	if !ok {
+		return "New"
+	}
+	if strings.EqualFold(extractJiraString(status, "name"), "Blocked") {
+		return "Blocked"
+	}
+
+	category, _ := status["statusCategory"].(map[string]interface{})
+	switch extractJiraString(category, "key") {
+	case "done":
+		return "Closed"
+	case "indeterminate":
+		return "Active"
+	default:
+		return "New"
+	}
+}
+
+// Worker id is the e-mail user part, falling back to the Server user name and Cloud account id.
+func extractJiraWorkerID(issue jira_api.Issue) string {
+	user, ok := issue.Fields["assignee"].(map[string]interface{})
+	if !ok {
+		user, _ = issue.Fields["reporter"].(map[string]interface{})
+	}
+
+	if email := extractJiraString(user, "emailAddress"); email != "" {
+		return strings.Split(email, "@")[0]
+	}
+	if name := extractJiraString(user, "name"); name != "" {
+		return name
+	}
+	return extractJiraString(user, "accountId")
+}
+
+func extractJiraString(fields map[string]interface{}, key string) string {
+	value, _ := fields[key].(string)
+	return value
+}
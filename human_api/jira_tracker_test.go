@@ -0,0 +1,105 @@
+package human_api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AlexeyBeley/human_api/jira_api"
+)
+
+func TestConvertJiraSnapshotToWobjects(t *testing.T) {
+	t.Run("Sprint issues and parents", func(t *testing.T) {
+		snapshot := jira_api.Snapshot{
+			Sprint: jira_api.Sprint{ID: 2, Name: "Sprint 2"},
+			Issues: []jira_api.Issue{{Key: "PROJ-2", Fields: map[string]interface{}{
+				"summary":   "task 2",
+				"issuetype": map[string]interface{}{"name": "Sub-task"},
+				"status":    map[string]interface{}{"name": "In Review", "statusCategory": map[string]interface{}{"key": "indeterminate"}},
+				"assignee":  map[string]interface{}{"emailAddress": "horey@example.com"},
+				"priority":  map[string]interface{}{"id": "3"},
+				"parent":    map[string]interface{}{"key": "PROJ-1"},
+			}}},
+			Parents: []jira_api.Issue{{Key: "PROJ-1", Fields: map[string]interface{}{
+				"summary":   "story",
+				"issuetype": map[string]interface{}{"name": "Story"},
+				"status":    map[string]interface{}{"name": "Blocked", "statusCategory": map[string]interface{}{"key": "indeterminate"}},
+				"reporter":  map[string]interface{}{"name": "horey"},
+			}}},
+		}
+
+		wobjects := ConvertJiraSnapshotToWobjects(snapshot)
+
+		wantTask := Wobject{Id: "PROJ-2", Title: "task 2", WorkerID: "horey", ChildrenIDs: &[]string{}, ParentID: "PROJ-1", Priority: 3, Status: "Active", Sprint: "Sprint 2", Type: "Task"}
+		wantStory := Wobject{Id: "PROJ-1", Title: "story", WorkerID: "horey", ChildrenIDs: &[]string{"PROJ-2"}, Status: "Blocked", Type: "UserStory"}
+		if !reflect.DeepEqual(*wobjects["PROJ-2"], wantTask) {
+			t.Errorf("ConvertJiraSnapshotToWobjects() task = %+v, want %+v", *wobjects["PROJ-2"], wantTask)
+		}
+		if !reflect.DeepEqual(*wobjects["PROJ-1"], wantStory) {
+			t.Errorf("ConvertJiraSnapshotToWobjects() story = %+v, want %+v", *wobjects["PROJ-1"], wantStory)
+		}
+	})
+}
+
+func TestJiraSubmitPlan(t *testing.T) {
+	t.Run("Issue keys", func(t *testing.T) {
+		calls := []string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/transitions"):
+				json.NewEncoder(w).Encode(map[string]interface{}{"transitions": []map[string]interface{}{
+					{"id": "21", "to": map[string]string{"name": "In Progress"}},
+				}})
+			case r.Method == http.MethodGet:
+				json.NewEncoder(w).Encode(jira_api.Issue{Fields: map[string]interface{}{"status": map[string]interface{}{"name": "To Do"}}})
+			case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+				calls = append(calls, r.Method+" "+r.URL.Path)
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(jira_api.Issue{Key: "PROJ-3"})
+			default:
+				calls = append(calls, r.Method+" "+r.URL.Path)
+				w.WriteHeader(http.StatusNoContent)
+			}
+		}))
+		defer server.Close()
+
+		baseById := map[string]*Wobject{
+			"PROJ-1": {Id: "PROJ-1", Title: "story", Priority: -1, LeftTime: -1, InvestedTime: -1, Type: "UserStory", ChildrenIDs: &[]string{"PROJ-2"}},
+			"PROJ-2": {Id: "PROJ-2", Title: "task 2", ParentID: "PROJ-1", Priority: -1, LeftTime: 5, InvestedTime: -1, Status: "New", Type: "Task", ChildrenIDs: &[]string{}},
+		}
+		wobjects := []*Wobject{
+			{Id: "PROJ-1", Title: "story", Priority: -1, LeftTime: -1, InvestedTime: -1, Type: "UserStory", ChildrenIDs: &[]string{"PROJ-2", "CreatePlease:task 3"}},
+			{Id: "PROJ-2", Title: "task 2", ParentID: "PROJ-1", Priority: -1, LeftTime: 3, InvestedTime: 2, Status: "Active", Type: "Task", ChildrenIDs: &[]string{}},
+			{Id: "CreatePlease:task 3", Title: "task 3", ParentID: "PROJ-1", Priority: -1, LeftTime: 4, InvestedTime: -1, Status: "New", Type: "Task", ChildrenIDs: &[]string{}},
+		}
+		plan, err := GeneratePlan(baseById, wobjects)
+		if err != nil {
+			t.Fatalf("GeneratePlan() error = %v", err)
+		}
+		journal, err := LoadJournal("")
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		tracker := NewJiraTracker(jira_api.Configuration{BaseURL: server.URL, Email: "horey@example.com", ProjectKey: "PROJ"})
+		err = ApplyPlan(tracker, plan, journal)
+		if err != nil {
+			t.Fatalf("ApplyPlan() error = %v", err)
+		}
+
+		want := []string{
+			"PUT /rest/api/2/issue/PROJ-1",
+			"PUT /rest/api/2/issue/PROJ-2",
+			"POST /rest/api/2/issue/PROJ-2/worklog",
+			"POST /rest/api/2/issue/PROJ-2/transitions",
+			"POST /rest/api/2/issue",
+			"PUT /rest/api/2/issue/PROJ-3",
+		}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("ApplyPlan() calls = %v, want %v", calls, want)
+		}
+	})
+}
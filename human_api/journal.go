@@ -0,0 +1,88 @@
+package human_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+)
+
+const journalFileName = "submit_journal.json"
+
+// Journal records the plan steps already applied to the tracker, so a failed
+// submit resumes where it stopped instead of creating wobjects twice.
+// A journal without FilePath is kept in memory only. OnChange is called after
+// the journal recorded a tracker change, a completed step or a created ID.
+type Journal struct {
+	FilePath       string            `json:"-"`
+	CreatedIds     map[string]string `json:"CreatedIds"`
+	CompletedSteps []string          `json:"CompletedSteps"`
+	OnChange       func() error      `json:"-"`
+}
+
+// Load the journal of a previous submit, a missing file is an empty journal.
+func LoadJournal(filePath string) (*Journal, error) {
+	journal := &Journal{FilePath: filePath, CreatedIds: make(map[string]string), CompletedSteps: []string{}}
+	if filePath == "" {
+		return journal, nil
+	}
+	exists, err := checkFileExists(filePath)
+	if err != nil || !exists {
+		return journal, err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, journal)
+	if err != nil {
+		return nil, fmt.Errorf("malformed journal '%s': %v", filePath, err)
+	}
+	if journal.CreatedIds == nil {
+		journal.CreatedIds = make(map[string]string)
+	}
+	return journal, nil
+}
+
+// Whether the submit changed the tracker.
+func (journal *Journal) Started() bool {
+	return len(journal.CompletedSteps) > 0 || len(journal.CreatedIds) > 0
+}
+
+func (journal *Journal) IsCompleted(stepKey string) bool {
+	return slices.Contains(journal.CompletedSteps, stepKey)
+}
+
+// Mark the step completed and write the journal to disk.
+func (journal *Journal) Complete(stepKey string) error {
+	journal.CompletedSteps = append(journal.CompletedSteps, stepKey)
+	return journal.save()
+}
+
+// Record the ID a create step got and write the journal to disk. Recorded as
+// soon as the tracker created the wobject, a failing follow-up call of the
+// create does not create it again on resume.
+func (journal *Journal) Created(createKey string, id string) error {
+	journal.CreatedIds[createKey] = id
+	return journal.save()
+}
+
+func (journal *Journal) save() error {
+	if journal.FilePath != "" {
+		jsonData, err := json.MarshalIndent(journal, "", "  ")
+		if err != nil {
+			return err
+		}
+		log.Printf("Writing journal: %v\n", journal.FilePath)
+		err = os.WriteFile(journal.FilePath, jsonData, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	if journal.OnChange != nil {
+		return journal.OnChange()
+	}
+	return nil
+}
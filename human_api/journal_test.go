@@ -0,0 +1,112 @@
+package human_api
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Tracker failing the first parent link.
+type flakyTracker struct {
+	recordingTracker
+	failed bool
+}
+
+func (tracker *flakyTracker) SetWobjectParent(wobject *Wobject) error {
+	if !tracker.failed {
+		tracker.failed = true
+		return errors.New("connection reset")
+	}
+	return tracker.recordingTracker.SetWobjectParent(wobject)
+}
+
+// Tracker creating the wobject, then failing the follow-up call of the first create.
+type followUpFailingTracker struct {
+	recordingTracker
+	failed bool
+}
+
+func (tracker *followUpFailingTracker) CreateWobject(wobject *Wobject) error {
+	err := tracker.recordingTracker.CreateWobject(wobject)
+	if err != nil || tracker.failed {
+		return err
+	}
+	tracker.failed = true
+	return errors.New("transition failed")
+}
+
+func TestApplyPlanResume(t *testing.T) {
+	t.Run("Rerun skips completed steps", func(t *testing.T) {
+		journalFilePath := filepath.Join(t.TempDir(), journalFileName)
+		newWobjects := func() []*Wobject {
+			return []*Wobject{
+				{Id: "11", Title: "task", ParentID: "CreatePlease:story", ChildrenIDs: &[]string{}},
+				{Id: "CreatePlease:story", Title: "story", ParentID: "-1", ChildrenIDs: &[]string{"11"}},
+			}
+		}
+		tracker := &flakyTracker{}
+
+		plan, err := GeneratePlan(map[string]*Wobject{}, newWobjects())
+		if err != nil {
+			t.Fatalf("GeneratePlan() error = %v", err)
+		}
+		journal, err := LoadJournal(journalFilePath)
+		if err != nil {
+			t.Fatalf("LoadJournal() error = %v", err)
+		}
+		err = ApplyPlan(tracker, plan, journal)
+		var trackerError *TrackerError
+		if !errors.As(err, &trackerError) || trackerError.Op != planActionSetParent {
+			t.Fatalf("ApplyPlan() first run error = %v", err)
+		}
+
+		plan, err = GeneratePlan(map[string]*Wobject{}, newWobjects())
+		if err != nil {
+			t.Fatalf("GeneratePlan() error = %v", err)
+		}
+		journal, err = LoadJournal(journalFilePath)
+		if err != nil {
+			t.Fatalf("LoadJournal() error = %v", err)
+		}
+		err = ApplyPlan(tracker, plan, journal)
+		if err != nil {
+			t.Fatalf("ApplyPlan() second run error = %v", err)
+		}
+
+		want := []string{"create story 1001", "update 11", "parent 11 1001"}
+		if !reflect.DeepEqual(tracker.calls, want) {
+			t.Errorf("ApplyPlan() calls = %v, want %v", tracker.calls, want)
+		}
+		if journal.CreatedIds["CreatePlease:story"] != "1001" || len(journal.CompletedSteps) != 3 {
+			t.Errorf("ApplyPlan() journal = %+v", journal)
+		}
+	})
+
+	t.Run("Failed create follow-up is not created again", func(t *testing.T) {
+		journalFilePath := filepath.Join(t.TempDir(), journalFileName)
+		tracker := &followUpFailingTracker{}
+		for run := range 2 {
+			plan, err := GeneratePlan(map[string]*Wobject{}, []*Wobject{{Id: "CreatePlease:story", Title: "story", ParentID: "-1", ChildrenIDs: &[]string{}}})
+			if err != nil {
+				t.Fatalf("GeneratePlan() error = %v", err)
+			}
+			journal, err := LoadJournal(journalFilePath)
+			if err != nil {
+				t.Fatalf("LoadJournal() error = %v", err)
+			}
+			err = ApplyPlan(tracker, plan, journal)
+			if (err != nil) != (run == 0) {
+				t.Fatalf("ApplyPlan() run %d error = %v", run, err)
+			}
+			if journal.CreatedIds["CreatePlease:story"] != "1001" {
+				t.Errorf("ApplyPlan() run %d journal = %+v", run, journal)
+			}
+		}
+
+		want := []string{"create story 1001", "update 1001"}
+		if !reflect.DeepEqual(tracker.calls, want) {
+			t.Errorf("ApplyPlan() calls = %v, want %v", tracker.calls, want)
+		}
+	})
+}
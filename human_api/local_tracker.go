@@ -0,0 +1,204 @@
+package human_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const trackerLocal = "local"
+
+// Tracker implementation keeping Wobjects by Id in a local JSON file.
+// The store has the same format as its snapshots, so no tracker is needed at all.
+type LocalTracker struct {
+	StoreFilePath string
+}
+
+func NewLocalTracker(storeFilePath string) *LocalTracker {
+	return &LocalTracker{StoreFilePath: storeFilePath}
+}
+
+func (tracker *LocalTracker) Download(dstFilePath string) error {
+	wobjects, err := tracker.load()
+	if err != nil {
+		return err
+	}
+	return writeLocalStore(wobjects, dstFilePath)
+}
+
+func (tracker *LocalTracker) DownloadWobjects(wobjectIds []string, dstFilePath string) error {
+	wobjects, err := tracker.load()
+	if err != nil {
+		return err
+	}
+
+	selected := make(map[string]*Wobject)
+	for _, wobjectId := range wobjectIds {
+		wobject, ok := wobjects[wobjectId]
+		if !ok {
+			return fmt.Errorf("wobject [%s] does not exist in local store '%s'", wobjectId, tracker.StoreFilePath)
+		}
+		selected[wobjectId] = wobject
+	}
+	return writeLocalStore(selected, dstFilePath)
+}
+
+func (tracker *LocalTracker) ReadWobjects(srcFilePath string) (map[string]*Wobject, error) {
+	return readLocalStore(srcFilePath)
+}
+
+// New wobjects get the next free numeric id.
+func (tracker *LocalTracker) CreateWobject(wobject *Wobject) error {
+	wobjects, err := tracker.load()
+	if err != nil {
+		return err
+	}
+
+	maxId := 0
+	for wobjId := range wobjects {
+		if intId, err := strconv.Atoi(wobjId); err == nil && intId > maxId {
+			maxId = intId
+		}
+	}
+
+	created := *wobject
+	created.Id = strconv.Itoa(maxId + 1)
+	created.ChildrenIDs = &[]string{}
+	created.Rev = 1
+	if created.ParentID == "" || created.ParentID == "-1" {
+		created.ParentID = "-1"
+	}
+	if created.LeftTime == -1 {
+		created.LeftTime = 0
+	}
+	if created.InvestedTime == -1 {
+		created.InvestedTime = 0
+	}
+	if created.Status == "" {
+		created.Status = "New"
+	}
+	if created.Priority == -1 {
+		created.Priority, _ = strconv.Atoi(GuessPriorityForRequestDict(*wobject))
+	}
+	wobjects[created.Id] = &created
+
+	err = tracker.save(wobjects)
+	if err != nil {
+		return err
+	}
+	wobject.Id = created.Id
+	return nil
+}
+
+// Left time replaces the stored one, invested time is added to it. A -1
+// Description is an unchanged comment, an empty Status an unreported one.
+// A wobject with a Rev is updated only if the stored one has the same Rev.
+func (tracker *LocalTracker) UpdateWobject(wobject *Wobject) error {
+	wobjects, err := tracker.load()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := wobjects[wobject.Id]
+	if !ok {
+		return fmt.Errorf("wobject [%s] [%s] does not exist in local store '%s'", wobject.Id, wobject.Title, tracker.StoreFilePath)
+	}
+	if wobject.Rev != 0 && wobject.Rev != stored.Rev {
+		return fmt.Errorf("wobject [%s] [%s] changed in local store '%s': revision %d, expected %d", wobject.Id, wobject.Title, tracker.StoreFilePath, stored.Rev, wobject.Rev)
+	}
+
+	stored.Rev++
+	stored.Title = wobject.Title
+	stored.WorkerID = wobject.WorkerID
+	if wobject.Status != "" {
+		stored.Status = wobject.Status
+	}
+	if wobject.Description != "-1" {
+		stored.Description = wobject.Description
+	}
+	if wobject.Sprint != "" {
+		stored.Sprint = wobject.Sprint
+	}
+	if wobject.Priority != -1 {
+		stored.Priority = wobject.Priority
+	}
+	if wobject.LeftTime != -1 {
+		stored.LeftTime = wobject.LeftTime
+	}
+	if wobject.InvestedTime > 0 {
+		stored.InvestedTime += wobject.InvestedTime
+	}
+
+	return tracker.save(wobjects)
+}
+
+func (tracker *LocalTracker) SetWobjectParent(wobject *Wobject) error {
+	wobjects, err := tracker.load()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := wobjects[wobject.Id]
+	if !ok {
+		return fmt.Errorf("wobject [%s] [%s] does not exist in local store '%s'", wobject.Id, wobject.Title, tracker.StoreFilePath)
+	}
+	if _, ok := wobjects[wobject.ParentID]; !ok {
+		return fmt.Errorf("wobject [%s] [%s] parent '%s' does not exist in local store '%s'", wobject.Id, wobject.Title, wobject.ParentID, tracker.StoreFilePath)
+	}
+	stored.ParentID = wobject.ParentID
+	stored.Rev++
+
+	return tracker.save(wobjects)
+}
+
+// Missing store is an empty one.
+func (tracker *LocalTracker) load() (map[string]*Wobject, error) {
+	exists, err := checkFileExists(tracker.StoreFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return make(map[string]*Wobject), nil
+	}
+	return readLocalStore(tracker.StoreFilePath)
+}
+
+func (tracker *LocalTracker) save(wobjects map[string]*Wobject) error {
+	return writeLocalStore(wobjects, tracker.StoreFilePath)
+}
+
+// Read the store and rebuild ChildrenIDs from the ParentIDs.
+func readLocalStore(filePath string) (map[string]*Wobject, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	wobjects := make(map[string]*Wobject)
+	err = json.Unmarshal(data, &wobjects)
+	if err != nil {
+		return nil, fmt.Errorf("malformed local store '%s': %v", filePath, err)
+	}
+
+	for _, wobject := range wobjects {
+		wobject.ChildrenIDs = &[]string{}
+	}
+	for wobjId, wobject := range wobjects {
+		parent, ok := wobjects[wobject.ParentID]
+		if !ok {
+			continue
+		}
+		*(parent.ChildrenIDs) = append(*(parent.ChildrenIDs), wobjId)
+	}
+	return wobjects, nil
+}
+
+func writeLocalStore(wobjects map[string]*Wobject, filePath string) error {
+	jsonData, err := json.MarshalIndent(wobjects, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, jsonData, 0644)
+}
@@ -0,0 +1,121 @@
+package human_api
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Write a configuration using the local tracker with a story and a task in the store.
+func writeLocalTestConfig(t *testing.T) (configFilePath string, config Configuration) {
+	dirPath := t.TempDir()
+	config = Configuration{
+		SprintName:         "sp1",
+		ReportsDirPath:     filepath.Join(dirPath, "reports"),
+		WorkerId:           "horey",
+		Tracker:            trackerLocal,
+		LocalStoreFilePath: filepath.Join(dirPath, "store.json"),
+	}
+
+	wobjects := map[string]*Wobject{
+		"1": {Id: "1", Title: "story", WorkerID: "horey", ParentID: "-1", Priority: 2, Status: "Active", Sprint: "sp1", Type: "UserStory"},
+		"2": {Id: "2", Title: "task", WorkerID: "horey", ParentID: "1", Priority: 2, LeftTime: 5, Status: "New", Sprint: "sp1", Type: "Task"},
+		"3": {Id: "3", Title: "other sprint", WorkerID: "horey", ParentID: "-1", Priority: 2, Status: "New", Sprint: "sp0", Type: "Task"},
+	}
+	err := writeLocalStore(wobjects, config.LocalStoreFilePath)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	configFilePath = filepath.Join(dirPath, "config.json")
+	err = os.WriteFile(configFilePath, data, 0644)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return configFilePath, config
+}
+
+func TestDailyRoutineLocalTracker(t *testing.T) {
+	t.Run("Extract and submit offline", func(t *testing.T) {
+		configFilePath, config := writeLocalTestConfig(t)
+		dateDirPath := filepath.Join(config.ReportsDirPath, config.SprintName, time.Now().Format("2006_01_02"))
+
+		err := DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() extract error = %v", err)
+		}
+
+		inputFilePath := filepath.Join(dateDirPath, inputFileName)
+		data, err := os.ReadFile(inputFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		wantLine := "[UserStory 1 #story] !!=!! -> Task 2 #task !!=!! Actions: \n"
+		if !strings.Contains(string(data), ">NEW:\n"+wantLine) || strings.Contains(string(data), "other sprint") {
+			t.Fatalf("DailyRoutine() generated input:\n%s", data)
+		}
+
+		input := strings.Replace(string(data), ">NEW:\n"+wantLine, ">NEW:\n", 1)
+		input = strings.Replace(input, ">ACTIVE:\n", ">ACTIVE:\n"+
+			"[UserStory 1 #story] !!=!! -> Task 2 #task !!=!! Actions: 3, +2, half way\n"+
+			"[UserStory 1 #story] !!=!! -> Task #new task !!=!! Actions: 4, +1, started\n", 1)
+		err = os.WriteFile(inputFilePath, []byte(input), 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() submit error = %v", err)
+		}
+
+		wobjects, err := readLocalStore(config.LocalStoreFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		task := wobjects["2"]
+		if task.Status != "Active" || task.LeftTime != 3 || task.InvestedTime != 2 || task.Description != "half way" {
+			t.Errorf("updated task = %+v", task)
+		}
+		created, ok := wobjects["4"]
+		if !ok {
+			t.Fatalf("created task is missing in store: %v", wobjects)
+		}
+		if created.Title != "new task" || created.ParentID != "1" || created.Status != "Active" || created.LeftTime != 4 || created.InvestedTime != 1 {
+			t.Errorf("created task = %+v", created)
+		}
+
+		data, err = os.ReadFile(filepath.Join(dateDirPath, postReportFileName))
+		if err != nil {
+			t.Fatalf("post report was not written: %v", err)
+		}
+		var report PostReport
+		err = json.Unmarshal(data, &report)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		// The story is a parent row, it is not updated.
+		if report.CreatedIds["CreatePlease:new task"] != "4" || len(report.Steps) != 3 || len(report.Wobjects) != 2 || report.Wobjects["2"].LeftTime != 3 {
+			t.Errorf("post report = %+v", report)
+		}
+
+		err = DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() after post report error = %v", err)
+		}
+		wobjectsAfter, err := readLocalStore(config.LocalStoreFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if len(wobjectsAfter) != len(wobjects) || wobjectsAfter["2"].InvestedTime != 2 {
+			t.Errorf("DailyRoutine() after post report changed the store: %v", wobjectsAfter)
+		}
+	})
+}
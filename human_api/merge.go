@@ -0,0 +1,132 @@
+package human_api
+
+import (
+	"log"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+const mergeSnapshotFileName = "merge_snapshot.json"
+
+// Wobject fields merged with the remote changes before a submit.
+var mergeFields = []string{"Title", "WorkerID", "Status", "Priority", "LeftTime", "ParentID"}
+
+// Three-way merge of the planned wobjects with their current remote state.
+// The pre report is the common base: fields changed remotely and not by the
+// user take the remote value, fields changed on both sides to different
+// values are conflicts. Updated wobjects get the remote Rev, so the tracker
+// rejects the update if the wobject changes again before it is applied, and
+// the remote State and Tags, so an unchanged status keeps its tracker state.
+// Steps completed by an earlier run are not merged.
+func MergeRemoteChanges(tracker Tracker, plan *Plan, journal *Journal, preReportFilePath string) error {
+	preWobjects, err := readTrackerWobjects(tracker, preReportFilePath)
+	if err != nil {
+		return err
+	}
+
+	// Wobjects created by the plan have no remote state yet.
+	wobjectIds := []string{}
+	for _, step := range plan.Steps {
+		if _, ok := preWobjects[step.Wobject.Id]; !ok || journal.IsCompleted(step.Key) || slices.Contains(wobjectIds, step.Wobject.Id) {
+			continue
+		}
+		wobjectIds = append(wobjectIds, step.Wobject.Id)
+	}
+	if len(wobjectIds) == 0 {
+		return nil
+	}
+	snapshotFilePath := filepath.Join(filepath.Dir(preReportFilePath), mergeSnapshotFileName)
+	err = tracker.DownloadWobjects(wobjectIds, snapshotFilePath)
+	if err != nil {
+		return &TrackerError{Op: "download", Err: err}
+	}
+	remoteWobjects, err := readTrackerWobjects(tracker, snapshotFilePath)
+	if err != nil {
+		return err
+	}
+
+	conflicts := []MergeConflict{}
+	for _, step := range plan.Steps {
+		wobject := step.Wobject
+		pre, ok := preWobjects[wobject.Id]
+		if !ok || journal.IsCompleted(step.Key) {
+			continue
+		}
+		remote, ok := remoteWobjects[wobject.Id]
+		if !ok {
+			conflicts = append(conflicts, MergeConflict{WobjectId: wobject.Id, Field: "Id", Input: wobject.Id, Remote: "deleted"})
+			continue
+		}
+
+		changedFields := []string{}
+		for _, change := range step.Changes {
+			changedFields = append(changedFields, change.Field)
+		}
+		for _, field := range mergeFields {
+			// Parent links are merged by their own step.
+			if (field == "ParentID") != (step.Action == planActionSetParent) {
+				continue
+			}
+			baseValue, remoteValue := getMergeField(pre, field), getMergeField(remote, field)
+			if baseValue == remoteValue {
+				continue
+			}
+			inputValue := getMergeField(wobject, field)
+			if slices.Contains(changedFields, field) {
+				if inputValue != remoteValue {
+					conflicts = append(conflicts, MergeConflict{WobjectId: wobject.Id, Field: field, Base: baseValue, Input: inputValue, Remote: remoteValue})
+				}
+				continue
+			}
+			if inputValue == "-1" {
+				continue
+			}
+			log.Printf("Merging remote change of wobject [%s] %s: %s -> %s\n", wobject.Id, field, baseValue, remoteValue)
+			setMergeField(wobject, field, remote)
+		}
+		wobject.Rev = remote.Rev
+		wobject.State = remote.State
+		wobject.Tags = remote.Tags
+	}
+
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+func getMergeField(wobject *Wobject, field string) string {
+	switch field {
+	case "Title":
+		return wobject.Title
+	case "WorkerID":
+		return wobject.WorkerID
+	case "Status":
+		return wobject.Status
+	case "Priority":
+		return strconv.Itoa(wobject.Priority)
+	case "LeftTime":
+		return strconv.Itoa(wobject.LeftTime)
+	case "ParentID":
+		return wobject.ParentID
+	}
+	return ""
+}
+
+func setMergeField(wobject *Wobject, field string, src *Wobject) {
+	switch field {
+	case "Title":
+		wobject.Title = src.Title
+	case "WorkerID":
+		wobject.WorkerID = src.WorkerID
+	case "Status":
+		wobject.Status = src.Status
+	case "Priority":
+		wobject.Priority = src.Priority
+	case "LeftTime":
+		wobject.LeftTime = src.LeftTime
+	case "ParentID":
+		wobject.ParentID = src.ParentID
+	}
+}
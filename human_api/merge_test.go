@@ -0,0 +1,139 @@
+package human_api
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Extract the daily report and move task 2 to ACTIVE with the given actions.
+func editLocalTestInput(t *testing.T, configFilePath string, files dailyFiles, actions string) {
+	t.Helper()
+	err := DailyRoutine(configFilePath)
+	if err != nil {
+		t.Fatalf("DailyRoutine() extract error = %v", err)
+	}
+	writeLocalTestInput(t, files, ">ACTIVE:\n", actions)
+}
+
+// Write the input moving task 2 from base to the section with the given actions.
+func writeLocalTestInput(t *testing.T, files dailyFiles, section, actions string) {
+	t.Helper()
+	data, err := os.ReadFile(files.Base)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	input := strings.Replace(string(data), "[UserStory 1 #story] !!=!! -> Task 2 #task !!=!! Actions: \n", "", 1)
+	input = strings.Replace(input, section, section+"[UserStory 1 #story] !!=!! -> Task 2 #task !!=!! Actions: "+actions+"\n", 1)
+	err = os.WriteFile(files.Input, []byte(input), 0644)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+}
+
+func changeLocalStore(t *testing.T, config Configuration, change func(wobjects map[string]*Wobject)) {
+	t.Helper()
+	wobjects, err := readLocalStore(config.LocalStoreFilePath)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	change(wobjects)
+	err = writeLocalStore(wobjects, config.LocalStoreFilePath)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+}
+
+func TestMergeRemoteChanges(t *testing.T) {
+	t.Run("Remote changes are kept", func(t *testing.T) {
+		configFilePath, config := writeLocalTestConfig(t)
+		files := newDailyFiles(filepath.Join(config.ReportsDirPath, config.SprintName, time.Now().Format("2006_01_02")))
+		editLocalTestInput(t, configFilePath, files, "3, +2, half way")
+		changeLocalStore(t, config, func(wobjects map[string]*Wobject) {
+			wobjects["2"].Title = "renamed task"
+			wobjects["2"].Priority = 1
+			wobjects["2"].Rev = 7
+		})
+
+		err := DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() submit error = %v", err)
+		}
+		wobjects, err := readLocalStore(config.LocalStoreFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		task := wobjects["2"]
+		if task.Title != "renamed task" || task.Priority != 1 || task.Status != "Active" || task.LeftTime != 3 || task.Rev != 8 {
+			t.Errorf("merged task = %+v", task)
+		}
+	})
+
+	t.Run("Conflicts are reported", func(t *testing.T) {
+		configFilePath, config := writeLocalTestConfig(t)
+		files := newDailyFiles(filepath.Join(config.ReportsDirPath, config.SprintName, time.Now().Format("2006_01_02")))
+		editLocalTestInput(t, configFilePath, files, "3, +2, half way")
+		changeLocalStore(t, config, func(wobjects map[string]*Wobject) {
+			wobjects["2"].LeftTime = 4
+			wobjects["2"].Status = "Blocked"
+		})
+
+		err := DailyRoutine(configFilePath)
+		var conflictError *ConflictError
+		if !errors.As(err, &conflictError) {
+			t.Fatalf("DailyRoutine() submit error = %v, want ConflictError", err)
+		}
+		want := []MergeConflict{
+			{WobjectId: "2", Field: "Status", Base: "New", Input: "Active", Remote: "Blocked"},
+			{WobjectId: "2", Field: "LeftTime", Base: "5", Input: "3", Remote: "4"},
+		}
+		if len(conflictError.Conflicts) != len(want) {
+			t.Fatalf("ConflictError.Conflicts = %+v, want %+v", conflictError.Conflicts, want)
+		}
+		for i := range want {
+			if conflictError.Conflicts[i] != want[i] {
+				t.Errorf("ConflictError.Conflicts[%d] = %+v, want %+v", i, conflictError.Conflicts[i], want[i])
+			}
+		}
+		wobjects, err := readLocalStore(config.LocalStoreFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if wobjects["2"].InvestedTime != 0 || wobjects["2"].LeftTime != 4 {
+			t.Errorf("conflicting submit changed the store: %+v", wobjects["2"])
+		}
+
+		writeLocalTestInput(t, files, ">BLOCKED:\n", "4, +2, half way")
+		err = DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() submit of the resolved input error = %v", err)
+		}
+		wobjects, err = readLocalStore(config.LocalStoreFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if wobjects["2"].Status != "Blocked" || wobjects["2"].LeftTime != 4 || wobjects["2"].InvestedTime != 2 {
+			t.Errorf("resolved task = %+v", wobjects["2"])
+		}
+	})
+
+	t.Run("Stale revision is rejected", func(t *testing.T) {
+		_, config := writeLocalTestConfig(t)
+		tracker := NewLocalTracker(config.LocalStoreFilePath)
+		changeLocalStore(t, config, func(wobjects map[string]*Wobject) {
+			wobjects["2"].Rev = 3
+		})
+
+		err := tracker.UpdateWobject(&Wobject{Id: "2", Title: "task", Priority: -1, LeftTime: 1, InvestedTime: -1, Rev: 2})
+		if err == nil || !strings.Contains(err.Error(), "revision 3, expected 2") {
+			t.Errorf("UpdateWobject() with stale revision error = %v", err)
+		}
+		err = tracker.UpdateWobject(&Wobject{Id: "2", Title: "task", Priority: -1, LeftTime: 1, InvestedTime: -1, Rev: 3})
+		if err != nil {
+			t.Errorf("UpdateWobject() error = %v", err)
+		}
+	})
+}
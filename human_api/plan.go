@@ -0,0 +1,241 @@
+package human_api
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	planActionCreate    = "create"
+	planActionUpdate    = "update"
+	planActionSetParent = "set parent"
+)
+
+// Wobject fields shown in a plan, in print order.
+var planFields = []string{"Type", "Title", "WorkerID", "Status", "Sprint", "Priority", "LeftTime", "InvestedTime", "Description"}
+
+// FieldChange is one patched field, Old is empty for created wobjects.
+// Unset fields of created wobjects are left out.
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// PlanStep is a single Tracker call.
+// Key is the action and the wobject Id at plan time, stable between runs.
+// Duration and Resumed are filled by ApplyPlan, Resumed steps were applied by an earlier run.
+type PlanStep struct {
+	Key      string
+	Action   string
+	Wobject  *Wobject
+	Changes  []FieldChange
+	Duration time.Duration
+	Resumed  bool
+}
+
+func newPlanStep(action string, wobject *Wobject) *PlanStep {
+	return &PlanStep{Key: action + " " + wobject.Id, Action: action, Wobject: wobject}
+}
+
+func (step *PlanStep) changesField(field string) bool {
+	return slices.ContainsFunc(step.Changes, func(change FieldChange) bool { return change.Field == field })
+}
+
+// Plan is the ordered list of Tracker calls a submit makes:
+// parents first, then children followed by their parent links.
+type Plan struct {
+	Steps []*PlanStep
+}
+
+// Generate the plan for the changed wobjects. baseById holds the wobjects as
+// they were before the user edited them, used for the old field values.
+// Parent links already present in base are not set again.
+func GeneratePlan(baseById map[string]*Wobject, wobjects []*Wobject) (*Plan, error) {
+	plan := &Plan{}
+
+	for _, wobject := range wobjects {
+		if len(*wobject.ChildrenIDs) == 0 {
+			continue
+		}
+		plan.addProvisionStep(baseById, wobject)
+	}
+
+	for _, wobject := range wobjects {
+		if len(*wobject.ChildrenIDs) != 0 {
+			continue
+		}
+		plan.addProvisionStep(baseById, wobject)
+		if wobject.ParentID == "-1" || wobject.ParentID == "" {
+			continue
+		}
+		if baseWobject, ok := baseById[wobject.Id]; ok && baseWobject.ParentID == wobject.ParentID {
+			continue
+		}
+		change := FieldChange{Field: "ParentID", New: wobject.ParentID}
+		if baseWobject, ok := baseById[wobject.Id]; ok {
+			change.Old = baseWobject.ParentID
+		}
+		step := newPlanStep(planActionSetParent, wobject)
+		step.Changes = []FieldChange{change}
+		plan.Steps = append(plan.Steps, step)
+	}
+	return plan, nil
+}
+
+func (plan *Plan) addProvisionStep(baseById map[string]*Wobject, wobject *Wobject) {
+	if wobject.Id == "-1" {
+		return
+	}
+
+	newDict := planFieldValues(wobject)
+
+	if strings.HasPrefix(wobject.Id, "CreatePlease:") {
+		step := newPlanStep(planActionCreate, wobject)
+		for _, field := range planFields {
+			if newDict[field] == "" || newDict[field] == "-1" {
+				continue
+			}
+			step.Changes = append(step.Changes, FieldChange{Field: field, New: newDict[field]})
+		}
+		plan.Steps = append(plan.Steps, step)
+		return
+	}
+
+	oldDict := map[string]string{}
+	if baseWobject, ok := baseById[wobject.Id]; ok {
+		oldDict = planFieldValues(baseWobject)
+	}
+	step := newPlanStep(planActionUpdate, wobject)
+	for _, field := range planFields {
+		if oldValue, ok := oldDict[field]; !ok || oldValue != newDict[field] {
+			step.Changes = append(step.Changes, FieldChange{Field: field, Old: oldDict[field], New: newDict[field]})
+		}
+	}
+	plan.Steps = append(plan.Steps, step)
+}
+
+// The planFields values of the wobject, the same for every tracker.
+func planFieldValues(wobject *Wobject) map[string]string {
+	return map[string]string{
+		"Type":         wobject.Type,
+		"Title":        wobject.Title,
+		"WorkerID":     wobject.WorkerID,
+		"Status":       wobject.Status,
+		"Sprint":       wobject.Sprint,
+		"Priority":     GuessPriorityForRequestDict(*wobject),
+		"LeftTime":     strconv.Itoa(wobject.LeftTime),
+		"InvestedTime": strconv.Itoa(wobject.InvestedTime),
+		"Description":  wobject.Description,
+	}
+}
+
+// Count the steps by action.
+func (plan *Plan) Summary() (creates, updates, parentLinks int) {
+	for _, step := range plan.Steps {
+		switch step.Action {
+		case planActionCreate:
+			creates++
+		case planActionUpdate:
+			updates++
+		case planActionSetParent:
+			parentLinks++
+		}
+	}
+	return creates, updates, parentLinks
+}
+
+// Terraform style: "+" creates, "~" updates, ">" parent links.
+func (plan *Plan) String() string {
+	var builder strings.Builder
+	creates, updates, parentLinks := plan.Summary()
+	if len(plan.Steps) == 0 {
+		return "No changes. Nothing to submit.\n"
+	}
+
+	for _, step := range plan.Steps {
+		wobject := step.Wobject
+		switch step.Action {
+		case planActionCreate:
+			fmt.Fprintf(&builder, "  + create %s %q\n", wobject.Type, wobject.Title)
+			for _, change := range step.Changes {
+				fmt.Fprintf(&builder, "      %-13s %q\n", change.Field+":", change.New)
+			}
+		case planActionUpdate:
+			fmt.Fprintf(&builder, "  ~ update %s %s %q\n", wobject.Type, wobject.Id, wobject.Title)
+			for _, change := range step.Changes {
+				fmt.Fprintf(&builder, "      %-13s %q -> %q\n", change.Field+":", change.Old, change.New)
+			}
+		case planActionSetParent:
+			fmt.Fprintf(&builder, "  > set parent of %s %s %q to %s\n", wobject.Type, planWobjectRef(wobject.Id), wobject.Title, planWobjectRef(wobject.ParentID))
+		}
+		builder.WriteString("\n")
+	}
+	fmt.Fprintf(&builder, "Plan: %d to create, %d to update, %d parent links to set.\n", creates, updates, parentLinks)
+	return builder.String()
+}
+
+// Wobjects created by the plan have no id yet.
+func planWobjectRef(wobjectId string) string {
+	if title, ok := strings.CutPrefix(wobjectId, "CreatePlease:"); ok {
+		return fmt.Sprintf("(new %q)", title)
+	}
+	return wobjectId
+}
+
+// Run the plan steps in order. Children of parents created by an earlier step
+// are linked to the new parent IDs. Every applied step is recorded in the
+// journal and steps it already holds are skipped, so a failed run resumes.
+// On failure the error tells how many steps were applied before it.
+func ApplyPlan(tracker Tracker, plan *Plan, journal *Journal) error {
+	for index, step := range plan.Steps {
+		if journal.IsCompleted(step.Key) {
+			log.Printf("skipping completed plan step: %s\n", step.Key)
+			if createdId, ok := journal.CreatedIds[step.Wobject.Id]; ok {
+				step.Wobject.Id = createdId
+			}
+			step.Resumed = true
+			continue
+		}
+
+		startTime := time.Now()
+		err := applyPlanStep(tracker, step, journal)
+		step.Duration = time.Since(startTime)
+		if err == nil {
+			err = journal.Complete(step.Key)
+		}
+		if err != nil {
+			return fmt.Errorf("applied %d of %d plan steps: %w", index, len(plan.Steps), err)
+		}
+	}
+	return nil
+}
+
+func applyPlanStep(tracker Tracker, step *PlanStep, journal *Journal) error {
+	wobject := step.Wobject
+	if step.Action == planActionUpdate && !step.changesField("Description") {
+		// The comment is submitted when it changed only, an unchanged one is not posted again.
+		update := *wobject
+		update.Description = "-1"
+		return provisionWobject(tracker, &update, journal)
+	}
+	if step.Action != planActionSetParent {
+		return provisionWobject(tracker, wobject, journal)
+	}
+
+	if newId, ok := journal.CreatedIds[wobject.ParentID]; ok {
+		wobject.ParentID = newId
+	}
+	if strings.HasPrefix(wobject.ParentID, "CreatePlease:") {
+		return fmt.Errorf("wobject [%s] [%s] parent '%s' was not created", wobject.Id, wobject.Title, wobject.ParentID)
+	}
+	err := tracker.SetWobjectParent(wobject)
+	if err != nil {
+		return &TrackerError{Op: planActionSetParent, WobjectId: wobject.Id, Err: err}
+	}
+	return nil
+}
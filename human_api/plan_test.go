@@ -0,0 +1,124 @@
+package human_api
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeneratePlan(t *testing.T) {
+	t.Run("Create, update and link", func(t *testing.T) {
+		baseById := map[string]*Wobject{
+			"1":  {Id: "1", Title: "story", ParentID: "-1", Priority: 2, LeftTime: -1, InvestedTime: -1, Status: "Active", Type: "UserStory", ChildrenIDs: &[]string{"11"}},
+			"11": {Id: "11", Title: "task", ParentID: "1", Priority: 2, LeftTime: 5, InvestedTime: -1, Status: "New", Type: "Task", ChildrenIDs: &[]string{}},
+		}
+		wobjects := []*Wobject{
+			{Id: "11", Title: "task", ParentID: "1", Priority: 2, LeftTime: 3, InvestedTime: 2, Status: "Active", Type: "Task", ChildrenIDs: &[]string{}},
+			{Id: "CreatePlease:story 2", Title: "story 2", ParentID: "-1", Priority: -1, LeftTime: -1, InvestedTime: -1, Status: "Active", Type: "UserStory", ChildrenIDs: &[]string{"CreatePlease:task 2"}},
+			{Id: "CreatePlease:task 2", Title: "task 2", ParentID: "CreatePlease:story 2", Priority: -1, LeftTime: 1, InvestedTime: -1, Status: "New", Type: "Task", ChildrenIDs: &[]string{}},
+		}
+
+		plan, err := GeneratePlan(baseById, wobjects)
+		if err != nil {
+			t.Fatalf("GeneratePlan() error = %v", err)
+		}
+
+		actions := []string{}
+		for _, step := range plan.Steps {
+			actions = append(actions, step.Action+" "+step.Wobject.Id)
+		}
+		wantActions := []string{"create CreatePlease:story 2", "update 11", "create CreatePlease:task 2", "set parent CreatePlease:task 2"}
+		if !reflect.DeepEqual(actions, wantActions) {
+			t.Fatalf("GeneratePlan() steps = %v, want %v", actions, wantActions)
+		}
+		wantChanges := []FieldChange{
+			{Field: "Status", Old: "New", New: "Active"},
+			{Field: "LeftTime", Old: "5", New: "3"},
+			{Field: "InvestedTime", Old: "-1", New: "2"},
+		}
+		if !reflect.DeepEqual(plan.Steps[1].Changes, wantChanges) {
+			t.Errorf("GeneratePlan() update changes = %+v, want %+v", plan.Steps[1].Changes, wantChanges)
+		}
+
+		output := plan.String()
+		for _, want := range []string{
+			"  ~ update Task 11 \"task\"\n      Status:       \"New\" -> \"Active\"\n",
+			"  + create Task \"task 2\"\n      Type:         \"Task\"\n      Title:        \"task 2\"\n      Status:       \"New\"\n      Priority:     \"2\"\n      LeftTime:     \"1\"\n\n",
+			"  > set parent of Task (new \"task 2\") \"task 2\" to (new \"story 2\")\n",
+			"Plan: 2 to create, 1 to update, 1 parent links to set.\n",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Plan.String() =\n%s\nmissing\n%s", output, want)
+			}
+		}
+	})
+}
+
+func TestDailyRoutinePlan(t *testing.T) {
+	t.Run("Plan does not submit", func(t *testing.T) {
+		configFilePath, config := writeLocalTestConfig(t)
+		dateDirPath := filepath.Join(config.ReportsDirPath, config.SprintName, time.Now().Format("2006_01_02"))
+
+		_, err := DailyRoutinePlan(configFilePath)
+		if err == nil {
+			t.Fatalf("DailyRoutinePlan() before extract error = nil")
+		}
+
+		err = DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() extract error = %v", err)
+		}
+		inputFilePath := filepath.Join(dateDirPath, inputFileName)
+		data, err := os.ReadFile(inputFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		input := strings.Replace(string(data), "Task 2 #task !!=!! Actions: \n", "Task 2 #task !!=!! Actions: 3\n", 1)
+		err = os.WriteFile(inputFilePath, []byte(input), 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		storeBefore, err := os.ReadFile(config.LocalStoreFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		plan, err := DailyRoutinePlan(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutinePlan() error = %v", err)
+		}
+		if len(plan.Steps) != 1 || plan.Steps[0].Action != planActionUpdate || plan.Steps[0].Wobject.Id != "2" {
+			t.Fatalf("DailyRoutinePlan() plan =\n%s", plan)
+		}
+		if reportDate := plan.Steps[0].Wobject.ReportDate; reportDate.Format("2006_01_02") != filepath.Base(dateDirPath) {
+			t.Errorf("DailyRoutinePlan() ReportDate = %v, want the daily directory date", reportDate)
+		}
+		storeAfter, err := os.ReadFile(config.LocalStoreFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if string(storeAfter) != string(storeBefore) {
+			t.Errorf("DailyRoutinePlan() changed the store")
+		}
+	})
+	t.Run("Parent rows are not updated", func(t *testing.T) {
+		configFilePath, config := writeLocalTestConfig(t)
+		files := newDailyFiles(filepath.Join(config.ReportsDirPath, config.SprintName, time.Now().Format("2006_01_02")))
+		err := DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() extract error = %v", err)
+		}
+		writeLocalTestInput(t, files, ">CLOSED:\n", "0")
+
+		plan, err := DailyRoutinePlan(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutinePlan() error = %v", err)
+		}
+		if len(plan.Steps) != 1 || plan.Steps[0].Wobject.Id != "2" || plan.Steps[0].Changes[0] != (FieldChange{Field: "Status", Old: "New", New: "Closed"}) {
+			t.Errorf("DailyRoutinePlan() plan =\n%s", plan)
+		}
+	})
+}
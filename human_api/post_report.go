@@ -0,0 +1,77 @@
+package human_api
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const postSnapshotFileName = "post_snapshot.json"
+
+// PostReport is written once a submit fully succeeded, it closes the day.
+type PostReport struct {
+	StartedAt  time.Time           `json:"StartedAt"`
+	FinishedAt time.Time           `json:"FinishedAt"`
+	Steps      []PostReportStep    `json:"Steps"`
+	CreatedIds map[string]string   `json:"CreatedIds"`
+	Wobjects   map[string]*Wobject `json:"Wobjects"`
+}
+
+// PostReportStep is an applied plan step. WobjectId is the tracker ID after the submit.
+type PostReportStep struct {
+	Key       string        `json:"Key"`
+	Action    string        `json:"Action"`
+	WobjectId string        `json:"WobjectId"`
+	Changes   []FieldChange `json:"Changes"`
+	Duration  string        `json:"Duration"`
+	Resumed   bool          `json:"Resumed"`
+}
+
+// Generate the post report of an applied plan with a fresh snapshot of the affected wobjects.
+// The tracker snapshot is kept next to the post report.
+func GeneratePostReport(tracker Tracker, plan *Plan, journal *Journal, startedAt time.Time, postReportFilePath string) (report PostReport, err error) {
+	report = PostReport{StartedAt: startedAt, Steps: []PostReportStep{}, CreatedIds: journal.CreatedIds, Wobjects: map[string]*Wobject{}}
+
+	wobjectIds := []string{}
+	known := make(map[string]bool)
+	for _, step := range plan.Steps {
+		report.Steps = append(report.Steps, PostReportStep{
+			Key:       step.Key,
+			Action:    step.Action,
+			WobjectId: step.Wobject.Id,
+			Changes:   step.Changes,
+			Duration:  step.Duration.String(),
+			Resumed:   step.Resumed,
+		})
+		if !known[step.Wobject.Id] {
+			known[step.Wobject.Id] = true
+			wobjectIds = append(wobjectIds, step.Wobject.Id)
+		}
+	}
+
+	if len(wobjectIds) > 0 {
+		snapshotFilePath := filepath.Join(filepath.Dir(postReportFilePath), postSnapshotFileName)
+		err = tracker.DownloadWobjects(wobjectIds, snapshotFilePath)
+		if err != nil {
+			return report, &TrackerError{Op: "download", Err: err}
+		}
+		report.Wobjects, err = readTrackerWobjects(tracker, snapshotFilePath)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+func WritePostReport(report PostReport, dstFilePath string) error {
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	log.Printf("Writing post report: %v\n", dstFilePath)
+	return os.WriteFile(dstFilePath, jsonData, 0644)
+}
@@ -0,0 +1,87 @@
+package human_api
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrefillDailyReportFromPreviousDay(t *testing.T) {
+	t.Run("Comments, ordering and notes", func(t *testing.T) {
+		configFilePath, config := writeLocalTestConfig(t)
+		wobjects, err := readLocalStore(config.LocalStoreFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		wobjects["5"] = &Wobject{Id: "5", Title: "review", WorkerID: "horey", ParentID: "1", Priority: 2, Status: "Active", Sprint: "sp1", Type: "Task"}
+		wobjects["6"] = &Wobject{Id: "6", Title: "deploy", WorkerID: "horey", ParentID: "1", Priority: 2, Status: "New", Sprint: "sp1", Type: "Task"}
+		wobjects["7"] = &Wobject{Id: "7", Title: "docs", WorkerID: "horey", ParentID: "1", Priority: 2, Status: "New", Sprint: "sp1", Type: "Task"}
+		// Moved by someone else, review was moved by the worker in the previous input.
+		wobjects["2"].Status = "Active"
+		err = writeLocalStore(wobjects, config.LocalStoreFilePath)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		previousFiles := newDailyFiles(filepath.Join(config.ReportsDirPath, config.SprintName, "2000_01_01"))
+		err = os.MkdirAll(previousFiles.DirPath, 0755)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		previousBase := "!!=!!H_ReportWorkerID!!=!! horey\n" +
+			">NEW:\n" +
+			"[UserStory 1 #story] !!=!! -> Task 2 #task !!=!! Actions: \n" +
+			"[UserStory 1 #story] !!=!! -> Task 5 #review !!=!! Actions: \n" +
+			"[UserStory 1 #story] !!=!! -> Task 6 #deploy !!=!! Actions: \n" +
+			">ACTIVE:\n>BLOCKED:\n>CLOSED:\n"
+		previousInput := "!!=!!H_ReportWorkerID!!=!! horey\n" +
+			">NEW:\n" +
+			"[UserStory 1 #story] !!=!! -> Task 6 #deploy !!=!! Actions: waiting for the release\n" +
+			"[UserStory 1 #story] !!=!! -> Task 2 #task !!=!! Actions: 4, +2, half way\n" +
+			">ACTIVE:\n" +
+			"[UserStory 1 #story] !!=!! -> Task 5 #review !!=!! Actions: 1, +1, started\n" +
+			">BLOCKED:\n>CLOSED:\n"
+		err = os.WriteFile(previousFiles.Base, []byte(previousBase), 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		err = os.WriteFile(previousFiles.Input, []byte(previousInput), 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() extract error = %v", err)
+		}
+
+		files := newDailyFiles(filepath.Join(config.ReportsDirPath, config.SprintName, time.Now().Format("2006_01_02")))
+		data, err := os.ReadFile(files.Input)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		want := "!!=!!H_ReportWorkerID!!=!! horey\n" +
+			">NEW:\n" +
+			"[UserStory 1 #story] !!=!! -> Task 6 #deploy !!=!! Actions: waiting for the release\n" +
+			"// new since the previous report\n" +
+			"[UserStory 1 #story] !!=!! -> Task 7 #docs !!=!! Actions: \n" +
+			">ACTIVE:\n" +
+			"// changed since the previous report: status NEW -> ACTIVE\n" +
+			"[UserStory 1 #story] !!=!! -> Task 2 #task !!=!! Actions: \n" +
+			"[UserStory 1 #story] !!=!! -> Task 5 #review !!=!! Actions: started\n" +
+			">BLOCKED:\n>CLOSED:\n"
+		if string(data) != want {
+			t.Errorf("DailyRoutine() input:\n%s\nwant\n%s", data, want)
+		}
+
+		plan, err := DailyRoutinePlan(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutinePlan() error = %v", err)
+		}
+		if len(plan.Steps) != 0 || !strings.Contains(plan.String(), "No changes") {
+			t.Errorf("DailyRoutinePlan() of the pre-filled report =\n%s", plan)
+		}
+	})
+}
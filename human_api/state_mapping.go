@@ -0,0 +1,205 @@
+package human_api
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Wobject statuses, one per .hapi section.
+var wobjectStatuses = []string{"New", "Active", "Blocked", "Closed"}
+
+// StateMapping maps the tracker states of wobject types to wobject statuses.
+// Types are the wobject types it applies to, every type when empty. States
+// maps every tracker state to a status, Statuses maps a status to the state
+// it is submitted as. Transitions are the fields the process requires when a
+// wobject moves into a state, by state and field reference name, e.g.
+// {"Resolved": {"System.Reason": "Fixed", "Microsoft.VSTS.Common.ResolvedBy": "{Worker}"}}.
+// Processes without a Blocked state mark blocked wobjects with BlockedTag:
+// the Blocked status is the state of the Active status with the tag. Wobjects
+// in a state mapped to Blocked, like the Blocked state of customized
+// processes, stay in it and are not tagged.
+type StateMapping struct {
+	Types       []string                     `json:"Types"`
+	States      map[string]string            `json:"States"`
+	Statuses    map[string]string            `json:"Statuses"`
+	Transitions map[string]map[string]string `json:"Transitions,omitempty"`
+	BlockedTag  string                       `json:"BlockedTag,omitempty"`
+}
+
+// Placeholder of a transition field value replaced by the wobject worker.
+const transitionWorkerPlaceholder = "{Worker}"
+
+// StateMappings are looked up in order, the first one of the type is used.
+type StateMappings []StateMapping
+
+// Tag of the process mappings, none of the processes has a Blocked state.
+// Their States still read a Blocked state added by a customized process.
+const defaultBlockedTag = "Blocked"
+
+// Tracker states no mapping covers. Work items in them are not reported.
+var errUnmappedState = errors.New("add it to StateMappings")
+
+// Mappings of the Azure DevOps process templates, by process name.
+var processStateMappings = map[string]StateMappings{
+	"Agile": {
+		{
+			States:     map[string]string{"New": "New", "Active": "Active", "Resolved": "Closed", "Closed": "Closed", "Removed": "Closed", "Blocked": "Blocked"},
+			Statuses:   map[string]string{"New": "New", "Active": "Active", "Closed": "Closed"},
+			BlockedTag: defaultBlockedTag,
+		},
+	},
+	"Scrum": {
+		{
+			Types:      []string{"Task"},
+			States:     map[string]string{"To Do": "New", "In Progress": "Active", "Done": "Closed", "Removed": "Closed", "Blocked": "Blocked"},
+			Statuses:   map[string]string{"New": "To Do", "Active": "In Progress", "Closed": "Done"},
+			BlockedTag: defaultBlockedTag,
+		},
+		{
+			Types:      []string{"Epic", "Feature"},
+			States:     map[string]string{"New": "New", "In Progress": "Active", "Done": "Closed", "Removed": "Closed", "Blocked": "Blocked"},
+			Statuses:   map[string]string{"New": "New", "Active": "In Progress", "Closed": "Done"},
+			BlockedTag: defaultBlockedTag,
+		},
+		{
+			States:     map[string]string{"New": "New", "Approved": "New", "Committed": "Active", "Done": "Closed", "Removed": "Closed", "Blocked": "Blocked"},
+			Statuses:   map[string]string{"New": "New", "Active": "Committed", "Closed": "Done"},
+			BlockedTag: defaultBlockedTag,
+		},
+	},
+	"CMMI": {
+		{
+			States:     map[string]string{"Proposed": "New", "Active": "Active", "Resolved": "Closed", "Closed": "Closed", "Removed": "Closed", "Blocked": "Blocked"},
+			Statuses:   map[string]string{"New": "Proposed", "Active": "Active", "Closed": "Closed"},
+			BlockedTag: defaultBlockedTag,
+		},
+	},
+	"Basic": {
+		{
+			States:     map[string]string{"To Do": "New", "Doing": "Active", "Done": "Closed", "Blocked": "Blocked"},
+			Statuses:   map[string]string{"New": "To Do", "Active": "Doing", "Closed": "Done"},
+			BlockedTag: defaultBlockedTag,
+		},
+	},
+}
+
+const defaultStateProcess = "Agile"
+
+func DefaultStateMappings() StateMappings {
+	return processStateMappings[defaultStateProcess]
+}
+
+// The configured mappings followed by the ones of the configured process, Agile by default.
+func LoadStateMappings(config Configuration) (StateMappings, error) {
+	process := config.StateProcess
+	if process == "" {
+		process = defaultStateProcess
+	}
+	processMappings, ok := processStateMappings[process]
+	if !ok {
+		return nil, fmt.Errorf("unknown StateProcess '%s', use one of Agile, Scrum, CMMI, Basic or set StateMappings", process)
+	}
+
+	mappings := slices.Concat(config.StateMappings, processMappings)
+	err := mappings.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+func (mappings StateMappings) Validate() error {
+	errors := []string{}
+	for _, mapping := range mappings {
+		for state, status := range mapping.States {
+			if !slices.Contains(wobjectStatuses, status) {
+				errors = append(errors, fmt.Sprintf("state '%s' of %v is mapped to unknown status '%s', use one of %v", state, mapping.Types, status, wobjectStatuses))
+			}
+		}
+		for status := range mapping.Statuses {
+			if !slices.Contains(wobjectStatuses, status) {
+				errors = append(errors, fmt.Sprintf("unknown status '%s' in Statuses of %v, use one of %v", status, mapping.Types, wobjectStatuses))
+			}
+		}
+		if _, ok := mapping.Statuses["Active"]; mapping.BlockedTag != "" && !ok {
+			errors = append(errors, fmt.Sprintf("BlockedTag of %v needs the state of the Active status", mapping.Types))
+		}
+		for state, fields := range mapping.Transitions {
+			if _, ok := mapping.States[state]; !ok {
+				errors = append(errors, fmt.Sprintf("transition to unknown state '%s' of %v", state, mapping.Types))
+			}
+			for field := range fields {
+				if field == "" || field == "System.State" {
+					errors = append(errors, fmt.Sprintf("invalid field '%s' in the transition to state '%s' of %v", field, state, mapping.Types))
+				}
+			}
+		}
+	}
+	if len(errors) > 0 {
+		return &ValidationError{Errors: errors}
+	}
+	return nil
+}
+
+func (mappings StateMappings) get(wobjectType string) (StateMapping, bool) {
+	for _, mapping := range mappings {
+		if len(mapping.Types) == 0 || slices.Contains(mapping.Types, wobjectType) {
+			return mapping, true
+		}
+	}
+	return StateMapping{}, false
+}
+
+// Status of a wobject in the tracker state. Unknown states are errors, not guesses.
+func (mappings StateMappings) Status(wobjectType string, state string) (string, error) {
+	mapping, ok := mappings.get(wobjectType)
+	if !ok {
+		return "", fmt.Errorf("no state mapping for wobject type '%s', %w", wobjectType, errUnmappedState)
+	}
+	status, ok := mapping.States[state]
+	if !ok {
+		return "", fmt.Errorf("unknown state '%s' of wobject type '%s', %w", state, wobjectType, errUnmappedState)
+	}
+	return status, nil
+}
+
+// Tracker state a wobject in the status is submitted as.
+func (mappings StateMappings) State(wobjectType string, status string) (string, error) {
+	mapping, ok := mappings.get(wobjectType)
+	if !ok {
+		return "", fmt.Errorf("no state mapping for wobject type '%s'", wobjectType)
+	}
+	state, ok := mapping.Statuses[status]
+	if !ok && status == "Blocked" && mapping.BlockedTag != "" {
+		state, ok = mapping.Statuses["Active"]
+	}
+	if !ok {
+		known := []string{}
+		for knownStatus := range mapping.Statuses {
+			known = append(known, knownStatus)
+		}
+		slices.Sort(known)
+		return "", fmt.Errorf("status '%s' has no state for wobject type '%s', the process supports %s", status, wobjectType, strings.Join(known, ", "))
+	}
+	return state, nil
+}
+
+// Tag marking blocked wobjects of the type, empty when the process has a Blocked state.
+func (mappings StateMappings) BlockedTag(wobjectType string) string {
+	mapping, ok := mappings.get(wobjectType)
+	if _, hasState := mapping.Statuses["Blocked"]; !ok || hasState {
+		return ""
+	}
+	return mapping.BlockedTag
+}
+
+// Fields to set when a wobject of the type moves into the state.
+func (mappings StateMappings) TransitionFields(wobjectType string, state string) map[string]string {
+	mapping, ok := mappings.get(wobjectType)
+	if !ok {
+		return nil
+	}
+	return mapping.Transitions[state]
+}
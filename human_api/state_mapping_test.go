@@ -0,0 +1,300 @@
+package human_api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/AlexeyBeley/human_api/azure_devops_api"
+)
+
+func newTestWit(id int, workItemType string, state string) azure_devops_api.WorkItem {
+	return azure_devops_api.WorkItem{ID: id, Rev: 3, Fields: map[string]interface{}{
+		"System.Title":         "task",
+		"System.State":         state,
+		"System.WorkItemType":  workItemType,
+		"System.IterationPath": "project\\sp1",
+		"System.AssignedTo":    map[string]interface{}{"uniqueName": "horey@example.com"},
+	}}
+}
+
+func TestStateMappings(t *testing.T) {
+	t.Run("Process mappings", func(t *testing.T) {
+		states, err := LoadStateMappings(Configuration{StateProcess: "Scrum"})
+		if err != nil {
+			t.Fatalf("LoadStateMappings() error = %v", err)
+		}
+		tests := []struct {
+			wobjectType string
+			state       string
+			status      string
+			submitted   string
+		}{
+			{"Task", "In Progress", "Active", "In Progress"},
+			{"Task", "Done", "Closed", "Done"},
+			{"ProductBacklogItem", "Approved", "New", "New"},
+			{"ProductBacklogItem", "Committed", "Active", "Committed"},
+			{"Feature", "In Progress", "Active", "In Progress"},
+		}
+		for _, tt := range tests {
+			status, err := states.Status(tt.wobjectType, tt.state)
+			if err != nil || status != tt.status {
+				t.Errorf("Status(%s, %s) = %s, %v, want %s", tt.wobjectType, tt.state, status, err, tt.status)
+			}
+			state, err := states.State(tt.wobjectType, tt.status)
+			if err != nil || state != tt.submitted {
+				t.Errorf("State(%s, %s) = %s, %v, want %s", tt.wobjectType, tt.status, state, err, tt.submitted)
+			}
+		}
+
+		_, err = states.Status("Task", "Resolved")
+		if err == nil || !strings.Contains(err.Error(), "unknown state 'Resolved'") {
+			t.Errorf("Status() of an unknown state error = %v", err)
+		}
+		if state, err := states.State("Task", "Blocked"); err != nil || state != "In Progress" {
+			t.Errorf("State(Task, Blocked) = %s, %v, want the Active state", state, err)
+		}
+		states = StateMappings{{States: map[string]string{"Open": "Active"}, Statuses: map[string]string{"Active": "Open"}}}
+		_, err = states.State("Task", "Blocked")
+		if err == nil || !strings.Contains(err.Error(), "supports Active") {
+			t.Errorf("State() of a status without state error = %v", err)
+		}
+	})
+
+	t.Run("Default mappings use process states", func(t *testing.T) {
+		// States of the work item types of the Azure DevOps process templates.
+		processStates := map[string]map[string][]string{
+			"Agile": {
+				"Epic": {"New", "Active", "Resolved", "Closed", "Removed"}, "Feature": {"New", "Active", "Resolved", "Closed", "Removed"},
+				"UserStory": {"New", "Active", "Resolved", "Closed", "Removed"}, "Task": {"New", "Active", "Closed", "Removed"},
+				"Bug": {"New", "Active", "Resolved", "Closed"},
+			},
+			"Scrum": {
+				"Epic": {"New", "In Progress", "Done", "Removed"}, "Feature": {"New", "In Progress", "Done", "Removed"},
+				"ProductBacklogItem": {"New", "Approved", "Committed", "Done", "Removed"}, "Bug": {"New", "Approved", "Committed", "Done", "Removed"},
+				"Task": {"To Do", "In Progress", "Done", "Removed"},
+			},
+			"CMMI": {
+				"Epic": {"Proposed", "Active", "Resolved", "Closed", "Removed"}, "Feature": {"Proposed", "Active", "Resolved", "Closed", "Removed"},
+				"Requirement": {"Proposed", "Active", "Resolved", "Closed", "Removed"}, "Task": {"Proposed", "Active", "Resolved", "Closed"},
+				"Bug": {"Proposed", "Active", "Resolved", "Closed"},
+			},
+			"Basic": {
+				"Epic": {"To Do", "Doing", "Done"}, "Issue": {"To Do", "Doing", "Done"}, "Task": {"To Do", "Doing", "Done"},
+			},
+		}
+		for process, typeStates := range processStates {
+			states, err := LoadStateMappings(Configuration{StateProcess: process})
+			if err != nil {
+				t.Fatalf("LoadStateMappings(%s) error = %v", process, err)
+			}
+			for wobjectType, known := range typeStates {
+				for _, status := range wobjectStatuses {
+					state, err := states.State(wobjectType, status)
+					if err != nil || !slices.Contains(known, state) {
+						t.Errorf("%s State(%s, %s) = '%s', %v, want one of %v", process, wobjectType, status, state, err, known)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Blocked tag", func(t *testing.T) {
+		tracker := &AzureDevopsTracker{}
+		wit := newTestWit(17, "Task", "Active")
+		wit.Fields["System.Tags"] = "ui; blocked"
+		wobject, err := ConvertWitToWobject(wit, tracker.states())
+		if err != nil || wobject.Status != "Blocked" {
+			t.Fatalf("ConvertWitToWobject() of a tagged work item = %+v, %v", wobject, err)
+		}
+
+		wobject.ParentID = "-1"
+		wobject.WorkerID = ""
+		requestDict, err := tracker.generateRequestDict(&wobject)
+		if err != nil || requestDict["State"] != "Active" || requestDict["Field:System.Tags"] != "" {
+			t.Errorf("generateRequestDict() of an unchanged Blocked status = %v, %v", requestDict, err)
+		}
+		wobject.Status = "Active"
+		requestDict, err = tracker.generateRequestDict(&wobject)
+		if err != nil || requestDict["State"] != "Active" || requestDict["Field:System.Tags"] != "ui" {
+			t.Errorf("generateRequestDict() of an unblocked work item = %v, %v", requestDict, err)
+		}
+		wobject = Wobject{Id: "18", Type: "Task", State: "New", Status: "Blocked", ParentID: "-1", ChildrenIDs: &[]string{}}
+		requestDict, err = tracker.generateRequestDict(&wobject)
+		if err != nil || requestDict["State"] != "Active" || requestDict["Field:System.Tags"] != "Blocked" {
+			t.Errorf("generateRequestDict() of a blocked work item = %v, %v", requestDict, err)
+		}
+
+		// A Blocked state of a customized process is read and kept, without the tag.
+		wobject, err = ConvertWitToWobject(newTestWit(19, "Task", "Blocked"), tracker.states())
+		if err != nil || wobject.Status != "Blocked" {
+			t.Fatalf("ConvertWitToWobject() in the Blocked state = %+v, %v", wobject, err)
+		}
+		wobject.ParentID = "-1"
+		wobject.WorkerID = ""
+		requestDict, err = tracker.generateRequestDict(&wobject)
+		if _, tagged := requestDict["Field:System.Tags"]; err != nil || requestDict["State"] != "Blocked" || tagged {
+			t.Errorf("generateRequestDict() of an unchanged Blocked state = %v, %v", requestDict, err)
+		}
+		wobject.Status = "Active"
+		requestDict, err = tracker.generateRequestDict(&wobject)
+		if _, tagged := requestDict["Field:System.Tags"]; err != nil || requestDict["State"] != "Active" || tagged {
+			t.Errorf("generateRequestDict() from the Blocked state = %v, %v", requestDict, err)
+		}
+	})
+
+	t.Run("Custom mappings", func(t *testing.T) {
+		config := Configuration{StateProcess: "CMMI", StateMappings: StateMappings{
+			{Types: []string{"Task"}, States: map[string]string{"Open": "New", "Waiting": "Blocked", "Shipped": "Closed"}, Statuses: map[string]string{"New": "Open", "Blocked": "Waiting", "Closed": "Shipped"}},
+		}}
+		states, err := LoadStateMappings(config)
+		if err != nil {
+			t.Fatalf("LoadStateMappings() error = %v", err)
+		}
+		if state, err := states.State("Task", "Blocked"); err != nil || state != "Waiting" {
+			t.Errorf("State(Task, Blocked) = %s, %v", state, err)
+		}
+		if state, err := states.State("Requirement", "New"); err != nil || state != "Proposed" {
+			t.Errorf("State(Requirement, New) = %s, %v", state, err)
+		}
+
+		config.StateMappings[0].States["Parked"] = "Sleeping"
+		_, err = LoadStateMappings(config)
+		if err == nil || !strings.Contains(err.Error(), "unknown status 'Sleeping'") {
+			t.Errorf("LoadStateMappings() with an unknown status error = %v", err)
+		}
+		_, err = LoadStateMappings(Configuration{StateProcess: "Kanban"})
+		if err == nil {
+			t.Errorf("LoadStateMappings() of an unknown process error = nil")
+		}
+	})
+
+	t.Run("Azure DevOps work items", func(t *testing.T) {
+		tracker := &AzureDevopsTracker{}
+		wobject, err := ConvertWitToWobject(newTestWit(12, "Task", "Resolved"), tracker.states())
+		if err != nil {
+			t.Fatalf("ConvertWitToWobject() error = %v", err)
+		}
+		if wobject.Status != "Closed" || wobject.State != "Resolved" || wobject.Rev != 3 {
+			t.Errorf("ConvertWitToWobject() = %+v", wobject)
+		}
+		_, err = ConvertWitToWobject(newTestWit(13, "Task", "Design"), tracker.states())
+		if err == nil || !strings.Contains(err.Error(), "work item 13: unknown state 'Design'") {
+			t.Errorf("ConvertWitToWobject() of an unknown state error = %v", err)
+		}
+
+		data, err := json.Marshal([]azure_devops_api.WorkItem{newTestWit(12, "Task", "Resolved"), newTestWit(13, "TestCase", "Design")})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		filePath := filepath.Join(t.TempDir(), preReportFileName)
+		err = os.WriteFile(filePath, data, 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		wobjects, err := ConvertAzureDevopsStatusToWobjects(filePath, StateMappings{{Types: []string{"Task"}, States: map[string]string{"Resolved": "Closed"}}})
+		var unmapped *UnmappedStatesError
+		if !errors.As(err, &unmapped) || len(unmapped.Errors) != 1 || !strings.Contains(unmapped.Errors[0], "work item 13: no state mapping for wobject type 'TestCase'") {
+			t.Errorf("ConvertAzureDevopsStatusToWobjects() with an unmapped work item error = %v", err)
+		}
+		if len(wobjects) != 1 || wobjects["12"] == nil {
+			t.Errorf("ConvertAzureDevopsStatusToWobjects() with an unmapped work item = %v", wobjects)
+		}
+
+		wobject.ParentID = "-1"
+		wobject.WorkerID = ""
+		requestDict, err := tracker.generateRequestDict(&wobject)
+		if err != nil || requestDict["State"] != "Resolved" {
+			t.Errorf("generateRequestDict() of an unchanged status State = %s, %v", requestDict["State"], err)
+		}
+		wobject.Status = "Active"
+		requestDict, err = tracker.generateRequestDict(&wobject)
+		if err != nil || requestDict["State"] != "Active" {
+			t.Errorf("generateRequestDict() of a changed status State = %s, %v", requestDict["State"], err)
+		}
+	})
+
+	t.Run("Transitions", func(t *testing.T) {
+		config := Configuration{StateMappings: StateMappings{{
+			Types:       []string{"Bug"},
+			States:      map[string]string{"Active": "Active", "Resolved": "Closed"},
+			Statuses:    map[string]string{"Active": "Active", "Closed": "Resolved"},
+			Transitions: map[string]map[string]string{"Resolved": {"System.Reason": "Fixed", "Microsoft.VSTS.Common.ResolvedBy": "{Worker}"}},
+		}}}
+		states, err := LoadStateMappings(config)
+		if err != nil {
+			t.Fatalf("LoadStateMappings() error = %v", err)
+		}
+		tracker := &AzureDevopsTracker{States: states}
+		wobject, err := ConvertWitToWobject(newTestWit(14, "Bug", "Active"), states)
+		if err != nil {
+			t.Fatalf("ConvertWitToWobject() error = %v", err)
+		}
+		wobject.ParentID = "-1"
+		wobject.WorkerID = "horey.tester"
+		wobject.Status = "Closed"
+		requestDict, err := tracker.generateRequestDict(&wobject)
+		if err != nil {
+			t.Fatalf("generateRequestDict() error = %v", err)
+		}
+		if requestDict["State"] != "Resolved" || requestDict["Field:System.Reason"] != "Fixed" || requestDict["Field:Microsoft.VSTS.Common.ResolvedBy"] != "Horey Tester" {
+			t.Errorf("generateRequestDict() of a transition = %v", requestDict)
+		}
+		wobject.State = "Resolved"
+		requestDict, err = tracker.generateRequestDict(&wobject)
+		if err != nil || requestDict["Field:System.Reason"] != "" {
+			t.Errorf("generateRequestDict() without a transition = %v, %v", requestDict, err)
+		}
+		wobject.WorkerID = "horey"
+		_, err = tracker.generateRequestDict(&wobject)
+		var validation *ValidationError
+		if !errors.As(err, &validation) || !strings.Contains(err.Error(), "worker id 'horey' is not in the 'name.surname' format") {
+			t.Errorf("generateRequestDict() of a worker id without a surname error = %v", err)
+		}
+
+		config.StateMappings[0].Transitions["Closed"] = map[string]string{"System.State": "Closed"}
+		_, err = LoadStateMappings(config)
+		if err == nil || !strings.Contains(err.Error(), "transition to unknown state 'Closed'") || !strings.Contains(err.Error(), "invalid field 'System.State'") {
+			t.Errorf("LoadStateMappings() with invalid transitions error = %v", err)
+		}
+	})
+
+	t.Run("Forbidden transition", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "TF401320: Rule Error for field Reason. Error code: Required, InvalidEmpty.", "typeKey": "RuleValidationException",
+				"customProperties": {"RuleValidationErrors": [{"fieldReferenceName": "System.Reason", "errorMessage": "TF401320: Rule Error for field Reason. Error code: Required, InvalidEmpty."}]}}`))
+		}))
+		defer server.Close()
+		req, err := http.NewRequest(http.MethodPatch, server.URL, nil)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		patchErr := azure_devops_api.Patch(azure_devops_api.Configuration{}, req)
+
+		wobject := &Wobject{Id: "15", Type: "Bug", State: "Active"}
+		err = transitionError(wobject, map[string]string{"State": "Resolved"}, patchErr)
+		var transition *TransitionError
+		if !errors.As(err, &transition) {
+			t.Fatalf("transitionError() = %v, want a TransitionError", err)
+		}
+		if !strings.Contains(err.Error(), "Bug '15' can not move from state 'Active' to 'Resolved'") || !strings.Contains(err.Error(), "System.Reason: TF401320") {
+			t.Errorf("transitionError() = %v", err)
+		}
+		var responseError *azure_devops_api.ResponseError
+		if !errors.As(err, &responseError) || responseError.StatusCode != http.StatusBadRequest {
+			t.Errorf("transitionError() does not wrap the response error: %v", err)
+		}
+
+		err = transitionError(wobject, map[string]string{"State": "Active"}, patchErr)
+		if errors.As(err, &transition) {
+			t.Errorf("transitionError() of an update keeping the state = %v", err)
+		}
+	})
+}
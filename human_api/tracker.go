@@ -0,0 +1,138 @@
+package human_api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlexeyBeley/human_api/azure_devops_api"
+	"github.com/AlexeyBeley/human_api/github_api"
+	"github.com/AlexeyBeley/human_api/jira_api"
+)
+
+const trackerAzureDevops = "azure_devops"
+
+// Work item cache shared by the daily downloads, in ReportsDirPath unless the Azure DevOps config sets CacheFilePath.
+const azureDevopsCacheFileName = "azure_devops_cache.json"
+
+// Tracker is the work item backend behind the daily routine.
+// Every backend keeps its own snapshot format and converts it to Wobjects.
+type Tracker interface {
+	// Download fetches the tracker's work items into a snapshot file.
+	Download(dstFilePath string) error
+	// ReadWobjects converts a snapshot written by Download to Wobjects by Id.
+	// Work items it can not map are listed by an UnmappedStatesError returned
+	// along with the other wobjects.
+	ReadWobjects(srcFilePath string) (map[string]*Wobject, error)
+	// DownloadWobjects fetches the given work items into a snapshot file readable by ReadWobjects.
+	DownloadWobjects(wobjectIds []string, dstFilePath string) error
+	// CreateWobject creates the work item and sets wobject.Id to the new ID. The ID
+	// is set even when a call following the create fails, it is resumed by an update.
+	CreateWobject(wobject *Wobject) error
+	// UpdateWobject patches an existing work item.
+	UpdateWobject(wobject *Wobject) error
+	// SetWobjectParent links the work item to wobject.ParentID.
+	SetWobjectParent(wobject *Wobject) error
+}
+
+// Create the Tracker selected by config.Tracker. Azure DevOps is the default.
+func NewTracker(config Configuration) (Tracker, error) {
+	switch config.Tracker {
+	case "", trackerAzureDevops:
+		azureDevopsConfig, err := azure_devops_api.LoadConfig(config.AzureDevopsConfigurationFilePath)
+		if err != nil {
+			return nil, err
+		}
+		if azureDevopsConfig.SprintName == "" {
+			azureDevopsConfig.SprintName = config.SprintName
+		}
+		if azureDevopsConfig.CacheFilePath == "" {
+			azureDevopsConfig.CacheFilePath = filepath.Join(config.ReportsDirPath, azureDevopsCacheFileName)
+		}
+		states, err := LoadStateMappings(config)
+		if err != nil {
+			return nil, err
+		}
+		return NewAzureDevopsTracker(azureDevopsConfig, states, config.wobjectTypes()), nil
+	case trackerJira:
+		jiraConfig, err := jira_api.LoadConfig(config.JiraConfigurationFilePath)
+		if err != nil {
+			return nil, err
+		}
+		return NewJiraTracker(jiraConfig), nil
+	case trackerGithub:
+		githubConfig, err := github_api.LoadConfig(config.GithubConfigurationFilePath)
+		if err != nil {
+			return nil, err
+		}
+		return NewGithubTracker(githubConfig), nil
+	case trackerLocal:
+		if config.LocalStoreFilePath == "" {
+			return nil, fmt.Errorf("parameter LocalStoreFilePath was not set in config")
+		}
+		return NewLocalTracker(config.LocalStoreFilePath), nil
+	default:
+		return nil, fmt.Errorf("unknown tracker: '%s'", config.Tracker)
+	}
+}
+
+// Read the snapshot, work items without a state mapping are left out and listed to the user.
+func readTrackerWobjects(tracker Tracker, filePath string) (map[string]*Wobject, error) {
+	wobjects, err := tracker.ReadWobjects(filePath)
+	var unmapped *UnmappedStatesError
+	if errors.As(err, &unmapped) {
+		fmt.Printf("Warning: %v\n", unmapped)
+		return wobjects, nil
+	}
+	return wobjects, err
+}
+
+// Submit changed wobjects: parents first, then children and their parent links.
+// Children of parents created in this run are linked to the new parent IDs.
+func SubmitWobjects(tracker Tracker, wobjects []*Wobject) error {
+	plan, err := GeneratePlan(map[string]*Wobject{}, wobjects)
+	if err != nil {
+		return err
+	}
+	journal, err := LoadJournal("")
+	if err != nil {
+		return err
+	}
+	return ApplyPlan(tracker, plan, journal)
+}
+
+// A create that failed after the tracker created the wobject is resumed as an update.
+func provisionWobject(tracker Tracker, wobject *Wobject, journal *Journal) error {
+	if wobject.Id == "-1" {
+		return nil
+	}
+	if createdId, ok := journal.CreatedIds[wobject.Id]; ok {
+		log.Printf("wobject '%s' was already created with id: %s\n", wobject.Id, createdId)
+		wobject.Id = createdId
+	}
+
+	if !strings.HasPrefix(wobject.Id, "CreatePlease:") {
+		err := tracker.UpdateWobject(wobject)
+		if err != nil {
+			return &TrackerError{Op: "update", WobjectId: wobject.Id, Err: err}
+		}
+		return nil
+	}
+
+	// Trackers set the ID when the create call returns, before their follow-up calls.
+	createKey := wobject.Id
+	createErr := tracker.CreateWobject(wobject)
+	if wobject.Id != createKey {
+		log.Printf("created wobject '%s' with id: %s\n", createKey, wobject.Id)
+		err := journal.Created(createKey, wobject.Id)
+		if err != nil {
+			return err
+		}
+	}
+	if createErr != nil {
+		return &TrackerError{Op: "create", WobjectId: createKey, Err: createErr}
+	}
+	return nil
+}
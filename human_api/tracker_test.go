@@ -0,0 +1,81 @@
+package human_api
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Tracker that records the calls it receives.
+type recordingTracker struct {
+	calls  []string
+	nextId int
+}
+
+func (tracker *recordingTracker) Download(dstFilePath string) error {
+	tracker.calls = append(tracker.calls, "download "+dstFilePath)
+	return nil
+}
+
+func (tracker *recordingTracker) DownloadWobjects(wobjectIds []string, dstFilePath string) error {
+	tracker.calls = append(tracker.calls, "download "+strings.Join(wobjectIds, ",")+" "+dstFilePath)
+	return nil
+}
+
+func (tracker *recordingTracker) ReadWobjects(srcFilePath string) (map[string]*Wobject, error) {
+	return map[string]*Wobject{}, nil
+}
+
+func (tracker *recordingTracker) CreateWobject(wobject *Wobject) error {
+	tracker.nextId++
+	wobject.Id = strconv.Itoa(1000 + tracker.nextId)
+	tracker.calls = append(tracker.calls, "create "+wobject.Title+" "+wobject.Id)
+	return nil
+}
+
+func (tracker *recordingTracker) UpdateWobject(wobject *Wobject) error {
+	tracker.calls = append(tracker.calls, "update "+wobject.Id)
+	return nil
+}
+
+func (tracker *recordingTracker) SetWobjectParent(wobject *Wobject) error {
+	tracker.calls = append(tracker.calls, "parent "+wobject.Id+" "+wobject.ParentID)
+	return nil
+}
+
+func TestSubmitWobjects(t *testing.T) {
+	t.Run("Parents first", func(t *testing.T) {
+		wobjects := []*Wobject{
+			{Id: "11", Title: "task", ParentID: "CreatePlease:story", ChildrenIDs: &[]string{}},
+			{Id: "CreatePlease:story", Title: "story", ParentID: "-1", ChildrenIDs: &[]string{"11"}},
+			{Id: "CreatePlease:orphan", Title: "orphan", ParentID: "-1", ChildrenIDs: &[]string{}},
+		}
+		tracker := &recordingTracker{}
+		err := SubmitWobjects(tracker, wobjects)
+		if err != nil {
+			t.Fatalf("SubmitWobjects() error = %v", err)
+		}
+		want := []string{"create story 1001", "update 11", "parent 11 1001", "create orphan 1002"}
+		if !reflect.DeepEqual(tracker.calls, want) {
+			t.Errorf("SubmitWobjects() calls = %v, want %v", tracker.calls, want)
+		}
+	})
+}
+
+// Tracker reading a snapshot with a work item it can not map.
+type unmappedTracker struct {
+	recordingTracker
+}
+
+func (tracker *unmappedTracker) ReadWobjects(srcFilePath string) (map[string]*Wobject, error) {
+	wobjects := map[string]*Wobject{"2": {Id: "2", Type: "Task", ChildrenIDs: &[]string{}}}
+	return wobjects, &UnmappedStatesError{Errors: []string{"work item 3: unknown state 'Design' of wobject type 'Task'"}}
+}
+
+func TestReadTrackerWobjects(t *testing.T) {
+	wobjects, err := readTrackerWobjects(&unmappedTracker{}, "pre_report.json")
+	if err != nil || len(wobjects) != 1 || wobjects["2"] == nil {
+		t.Errorf("readTrackerWobjects() with an unmapped work item = %v, %v, want the mapped wobjects", wobjects, err)
+	}
+}
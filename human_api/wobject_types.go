@@ -0,0 +1,149 @@
+package human_api
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// WobjectType is a work item type of the project. UrlName is the type name in
+// the tracker, Leaf types are the ones worked on and reported with times.
+// Parents are the types a wobject of the type can be a child of, any non leaf
+// type when empty.
+type WobjectType struct {
+	Name    string   `json:"Name"`
+	UrlName string   `json:"UrlName"`
+	Leaf    bool     `json:"Leaf"`
+	Parents []string `json:"Parents"`
+}
+
+// WobjectTypes is the type registry of a project.
+type WobjectTypes []WobjectType
+
+// Types used when the configuration has none.
+func DefaultWobjectTypes() WobjectTypes {
+	return WobjectTypes{
+		{Name: "Feature", UrlName: "Feature"},
+		{Name: "UserStory", UrlName: "User Story"},
+		{Name: "DevOpsSupport", UrlName: "DevOps Support"},
+		{Name: "EscapedBug", UrlName: "Escaped Bug"},
+		{Name: "Task", UrlName: "Task", Leaf: true},
+		{Name: "Bug", UrlName: "Bug", Leaf: true},
+	}
+}
+
+// Implemented by trackers that can read the type registry from the project.
+// The tracker uses the discovered types from then on.
+type WobjectTypeDiscoverer interface {
+	DiscoverWobjectTypes() (WobjectTypes, error)
+}
+
+// The configured types, the default ones when the configuration has none.
+func LoadWobjectTypes(config Configuration) (WobjectTypes, error) {
+	if len(config.WobjectTypes) == 0 {
+		return DefaultWobjectTypes(), nil
+	}
+	err := config.WobjectTypes.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return config.WobjectTypes, nil
+}
+
+// Registry used by the parser and the validation, DefaultWobjectTypes when the configuration has none.
+func (config Configuration) wobjectTypes() WobjectTypes {
+	if len(config.WobjectTypes) == 0 {
+		return DefaultWobjectTypes()
+	}
+	return config.WobjectTypes
+}
+
+// Replace the configured types by the ones of the tracker if DiscoverWobjectTypes is set.
+func discoverWobjectTypes(config *Configuration, tracker Tracker) error {
+	if !config.DiscoverWobjectTypes {
+		return nil
+	}
+	discoverer, ok := tracker.(WobjectTypeDiscoverer)
+	if !ok {
+		return fmt.Errorf("tracker '%s' can not discover wobject types, set WobjectTypes in the config", config.Tracker)
+	}
+	types, err := discoverer.DiscoverWobjectTypes()
+	if err != nil {
+		return &TrackerError{Op: "discover types", Err: err}
+	}
+	err = types.Validate()
+	if err != nil {
+		return err
+	}
+	config.WobjectTypes = types
+	return nil
+}
+
+func (types WobjectTypes) Validate() error {
+	errors := []string{}
+	for index, wobjectType := range types {
+		if wobjectType.Name == "" || strings.ContainsAny(wobjectType.Name, " \t\r\n#\\") {
+			errors = append(errors, fmt.Sprintf("invalid wobject type name '%s'", wobjectType.Name))
+		}
+		if wobjectType.UrlName == "" {
+			errors = append(errors, fmt.Sprintf("wobject type '%s' has no UrlName", wobjectType.Name))
+		}
+		if slices.ContainsFunc(types[:index], func(other WobjectType) bool { return other.Name == wobjectType.Name }) {
+			errors = append(errors, fmt.Sprintf("wobject type '%s' is defined twice", wobjectType.Name))
+		}
+		for _, parent := range wobjectType.Parents {
+			if _, ok := types.Get(parent); !ok {
+				errors = append(errors, fmt.Sprintf("wobject type '%s' has unknown parent type '%s'", wobjectType.Name, parent))
+			}
+		}
+	}
+	if len(errors) > 0 {
+		return &ValidationError{Errors: errors}
+	}
+	return nil
+}
+
+func (types WobjectTypes) Get(name string) (WobjectType, bool) {
+	index := slices.IndexFunc(types, func(wobjectType WobjectType) bool { return wobjectType.Name == name })
+	if index == -1 {
+		return WobjectType{}, false
+	}
+	return types[index], true
+}
+
+func (types WobjectTypes) IsLeaf(name string) bool {
+	wobjectType, ok := types.Get(name)
+	return ok && wobjectType.Leaf
+}
+
+func (types WobjectTypes) Names(leaf bool) []string {
+	names := []string{}
+	for _, wobjectType := range types {
+		if wobjectType.Leaf == leaf {
+			names = append(names, wobjectType.Name)
+		}
+	}
+	return names
+}
+
+// Check that a wobject of the child type can be a child of the parent type.
+func (types WobjectTypes) CheckParent(child string, parent string) error {
+	childType, ok := types.Get(child)
+	if !ok {
+		return fmt.Errorf("unknown wobject type '%s'", child)
+	}
+	parentType, ok := types.Get(parent)
+	if !ok {
+		return fmt.Errorf("unknown wobject type '%s'", parent)
+	}
+	if len(childType.Parents) == 0 {
+		if parentType.Leaf {
+			return fmt.Errorf("'%s' can not be a child of '%s', leaf types have no children", child, parent)
+		}
+		return nil
+	}
+	if !slices.Contains(childType.Parents, parent) {
+		return fmt.Errorf("'%s' can not be a child of '%s', use one of %v", child, parent, childType.Parents)
+	}
+	return nil
+}
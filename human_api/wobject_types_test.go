@@ -0,0 +1,106 @@
+package human_api
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testWobjectTypes = WobjectTypes{
+	{Name: "Epic", UrlName: "Epic"},
+	{Name: "Story", UrlName: "Product Backlog Item", Parents: []string{"Epic"}},
+	{Name: "Chore", UrlName: "Chore", Leaf: true, Parents: []string{"Story"}},
+}
+
+func TestWobjectTypes(t *testing.T) {
+	t.Run("Parents", func(t *testing.T) {
+		types := DefaultWobjectTypes()
+		if err := types.CheckParent("Bug", "UserStory"); err != nil {
+			t.Errorf("CheckParent(Bug, UserStory) error = %v", err)
+		}
+		if err := types.CheckParent("Task", "Bug"); err == nil {
+			t.Errorf("CheckParent(Task, Bug) error = nil")
+		}
+		if err := testWobjectTypes.CheckParent("Chore", "Epic"); err == nil || !strings.Contains(err.Error(), "use one of [Story]") {
+			t.Errorf("CheckParent(Chore, Epic) error = %v", err)
+		}
+	})
+
+	t.Run("Invalid registry", func(t *testing.T) {
+		types := WobjectTypes{
+			{Name: "User Story", UrlName: "User Story"},
+			{Name: "Task", Leaf: true, Parents: []string{"Story"}},
+			{Name: "Task", UrlName: "Task"},
+		}
+		_, err := LoadWobjectTypes(Configuration{WobjectTypes: types})
+		if err == nil {
+			t.Fatalf("LoadWobjectTypes() error = nil")
+		}
+		for _, want := range []string{"invalid wobject type name 'User Story'", "'Task' has no UrlName", "unknown parent type 'Story'", "'Task' is defined twice"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("LoadWobjectTypes() error = %v, want %s", err, want)
+			}
+		}
+		if types, err := LoadWobjectTypes(Configuration{}); err != nil || len(types) != len(DefaultWobjectTypes()) {
+			t.Errorf("LoadWobjectTypes() without types = %v, %v, want the default types", types, err)
+		}
+	})
+
+	t.Run("Configured types", func(t *testing.T) {
+		configFilePath, config := writeLocalTestConfig(t)
+		config.WobjectTypes = testWobjectTypes
+		data, err := json.Marshal(config)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		err = os.WriteFile(configFilePath, data, 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		changeLocalStore(t, config, func(wobjects map[string]*Wobject) {
+			wobjects["1"].Type = "Story"
+			wobjects["2"].Type = "Chore"
+			wobjects["3"].Type = "Epic"
+		})
+
+		err = DailyRoutine(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutine() extract error = %v", err)
+		}
+		files := newDailyFiles(filepath.Join(config.ReportsDirPath, config.SprintName, time.Now().Format("2006_01_02")))
+		data, err = os.ReadFile(files.Input)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		line := "[Story 1 #story] !!=!! -> Chore 2 #task !!=!! Actions: \n"
+		if !strings.Contains(string(data), line) {
+			t.Fatalf("DailyRoutine() input:\n%s", data)
+		}
+
+		input := strings.Replace(string(data), ">ACTIVE:\n", ">ACTIVE:\n[Story 1 #story] !!=!! -> Task #new task !!=!! Actions: 4, +1\n", 1)
+		err = os.WriteFile(files.Input, []byte(input), 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		_, err = DailyRoutinePlan(configFilePath)
+		if err == nil || !strings.Contains(err.Error(), "unsupported wobject type 'Task'") {
+			t.Errorf("DailyRoutinePlan() with an unknown type error = %v", err)
+		}
+
+		input = strings.Replace(string(data), ">ACTIVE:\n", ">ACTIVE:\n[Story 1 #story] !!=!! -> Chore #new chore !!=!! Actions: 4, +1\n", 1)
+		err = os.WriteFile(files.Input, []byte(input), 0644)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		plan, err := DailyRoutinePlan(configFilePath)
+		if err != nil {
+			t.Fatalf("DailyRoutinePlan() error = %v", err)
+		}
+		if creates, _, _ := plan.Summary(); creates != 1 {
+			t.Errorf("DailyRoutinePlan() =\n%s", plan)
+		}
+	})
+}
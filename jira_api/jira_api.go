@@ -0,0 +1,365 @@
+package jira_api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Configuration works for both Jira Cloud and Jira Server/Data Center.
+// Cloud authenticates with Email + APIToken, Server with a PersonalAccessToken.
+type Configuration struct {
+	BaseURL             string `json:"BaseURL"`
+	Email               string `json:"Email"`
+	APIToken            string `json:"APIToken"`
+	PersonalAccessToken string `json:"PersonalAccessToken"`
+	ProjectKey          string `json:"ProjectKey"`
+	BoardID             int    `json:"BoardID"`
+	SprintName          string `json:"SprintName"`
+	// Jira status name to transition to, by hapi status (New, Active, Blocked, Closed).
+	StatusNames map[string]string `json:"StatusNames"`
+	// Jira Cloud account id by hapi worker id. Server uses the worker id as user name.
+	AccountIds map[string]string `json:"AccountIds"`
+}
+
+type Sprint struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+type Issue struct {
+	ID     string                 `json:"id"`
+	Key    string                 `json:"key"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// Snapshot is the JSON file DownloadSprintIssues writes.
+// Parents holds parents of sprint issues which are not part of the sprint.
+type Snapshot struct {
+	Sprint  Sprint  `json:"sprint"`
+	Issues  []Issue `json:"issues"`
+	Parents []Issue `json:"parents"`
+}
+
+type sprintsPage struct {
+	StartAt int      `json:"startAt"`
+	IsLast  bool     `json:"isLast"`
+	Values  []Sprint `json:"values"`
+}
+
+type issuesPage struct {
+	StartAt    int     `json:"startAt"`
+	MaxResults int     `json:"maxResults"`
+	Total      int     `json:"total"`
+	Issues     []Issue `json:"issues"`
+}
+
+type transition struct {
+	ID string `json:"id"`
+	To struct {
+		Name string `json:"name"`
+	} `json:"to"`
+}
+
+const issueFields = "summary,description,status,issuetype,assignee,reporter,parent,priority,timetracking,timeestimate,timespent"
+
+var defaultStatusNames = map[string]string{
+	"New":     "To Do",
+	"Active":  "In Progress",
+	"Blocked": "Blocked",
+	"Closed":  "Done",
+}
+
+func LoadConfig(configFilePath string) (config Configuration, err error) {
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+func ValidateConfig(config Configuration) error {
+	if config.BaseURL == "" {
+		return fmt.Errorf("parameter BaseURL was not set in config")
+	}
+	if config.ProjectKey == "" {
+		return fmt.Errorf("parameter ProjectKey was not set in config")
+	}
+	return nil
+}
+
+func getClient() http.Client {
+	return http.Client{Timeout: 10 * time.Second}
+}
+
+func createRequest(config Configuration, ctx context.Context, RequestPath string, httpMethod string, body io.Reader) (*http.Request, error) {
+	requestUrl := strings.TrimRight(config.BaseURL, "/") + "/rest/" + RequestPath
+
+	req, err := http.NewRequestWithContext(ctx, httpMethod, requestUrl, body)
+	if err != nil {
+		return req, err
+	}
+
+	if config.Email != "" {
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(config.Email+":"+config.APIToken)))
+	} else {
+		req.Header.Set("Authorization", "Bearer "+config.PersonalAccessToken)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// Send the request and decode the JSON response into ret, unless ret is nil.
+func call(config Configuration, httpMethod string, RequestPath string, body interface{}, ret interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		postData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error marshaling JSON: %v", err)
+		}
+		bodyReader = bytes.NewBuffer(postData)
+	}
+
+	req, err := createRequest(config, context.Background(), RequestPath, httpMethod, bodyReader)
+	if err != nil {
+		return err
+	}
+	client := getClient()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("received error in HTTP clinet request: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respData, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP status error: %s %s: %d %s %s", httpMethod, RequestPath, resp.StatusCode, resp.Status, string(respData))
+	}
+
+	if ret == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(ret)
+}
+
+func GetSprint(config Configuration) (sprint Sprint, err error) {
+	startAt := 0
+	for {
+		var page sprintsPage
+		err = call(config, http.MethodGet, fmt.Sprintf("agile/1.0/board/%d/sprint?startAt=%d", config.BoardID, startAt), nil, &page)
+		if err != nil {
+			return sprint, err
+		}
+		for _, value := range page.Values {
+			if value.Name == config.SprintName {
+				return value, nil
+			}
+		}
+		if page.IsLast || len(page.Values) == 0 {
+			break
+		}
+		startAt += len(page.Values)
+	}
+	return sprint, fmt.Errorf("was not able to find Sprint by name: %s", config.SprintName)
+}
+
+func GetSprintIssues(config Configuration, sprint Sprint) (issues []Issue, err error) {
+	startAt := 0
+	for {
+		var page issuesPage
+		err = call(config, http.MethodGet, fmt.Sprintf("agile/1.0/sprint/%d/issue?startAt=%d&maxResults=50&fields=%s", sprint.ID, startAt, issueFields), nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, page.Issues...)
+		startAt += len(page.Issues)
+		if len(page.Issues) == 0 || startAt >= page.Total {
+			break
+		}
+	}
+	return issues, nil
+}
+
+func GetIssue(config Configuration, key string) (issue Issue, err error) {
+	err = call(config, http.MethodGet, fmt.Sprintf("api/2/issue/%s?fields=%s", url.PathEscape(key), issueFields), nil, &issue)
+	return issue, err
+}
+
+func DownloadSprintIssues(config Configuration, dstFilePath string) error {
+	err := ValidateConfig(config)
+	if err != nil {
+		return err
+	}
+
+	sprint, err := GetSprint(config)
+	if err != nil {
+		return err
+	}
+
+	issues, err := GetSprintIssues(config, sprint)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("fetched %d issues of sprint %s\n", len(issues), sprint.Name)
+
+	snapshot := Snapshot{Sprint: sprint, Issues: issues, Parents: []Issue{}}
+	known := make(map[string]bool)
+	for _, issue := range issues {
+		known[issue.Key] = true
+	}
+	for _, issue := range issues {
+		parentKey := GetParentKey(issue)
+		if parentKey == "" || known[parentKey] {
+			continue
+		}
+		parent, err := GetIssue(config, parentKey)
+		if err != nil {
+			return err
+		}
+		known[parentKey] = true
+		snapshot.Parents = append(snapshot.Parents, parent)
+	}
+
+	return CacheToFile(snapshot, dstFilePath)
+}
+
+// Download the issues with the given keys, parents are not fetched.
+func DownloadIssues(config Configuration, keys []string, dstFilePath string) error {
+	snapshot := Snapshot{Issues: []Issue{}, Parents: []Issue{}}
+	for _, key := range keys {
+		issue, err := GetIssue(config, key)
+		if err != nil {
+			return err
+		}
+		snapshot.Issues = append(snapshot.Issues, issue)
+	}
+
+	return CacheToFile(snapshot, dstFilePath)
+}
+
+func CacheToFile(snapshot Snapshot, dstFilePath string) (err error) {
+	jsonData, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(dstFilePath, jsonData, 0644)
+}
+
+func ReadSnapshotFromFile(filePath string) (snapshot Snapshot, err error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return snapshot, err
+	}
+
+	err = json.Unmarshal(data, &snapshot)
+	return snapshot, err
+}
+
+func GetParentKey(issue Issue) string {
+	parent, ok := issue.Fields["parent"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	key, _ := parent["key"].(string)
+	return key
+}
+
+// Create the issue and return its key.
+func CreateIssue(config Configuration, fields map[string]interface{}) (string, error) {
+	fields["project"] = map[string]string{"key": config.ProjectKey}
+
+	var created Issue
+	err := call(config, http.MethodPost, "api/2/issue", map[string]interface{}{"fields": fields}, &created)
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("Created new Jira issue: %s\n", created.Key)
+	return created.Key, nil
+}
+
+func UpdateIssue(config Configuration, key string, fields map[string]interface{}) error {
+	fmt.Printf("Updating Jira issue: %s %v\n", key, fields)
+	return call(config, http.MethodPut, "api/2/issue/"+url.PathEscape(key), map[string]interface{}{"fields": fields}, nil)
+}
+
+func SetIssueParent(config Configuration, key, parentKey string) error {
+	return UpdateIssue(config, key, map[string]interface{}{"parent": map[string]string{"key": parentKey}})
+}
+
+// Move the issue to the Jira status configured for the hapi status.
+func TransitionIssue(config Configuration, key, status string) error {
+	statusName := GetStatusName(config, status)
+
+	issue, err := GetIssue(config, key)
+	if err != nil {
+		return err
+	}
+	if currentStatus, ok := issue.Fields["status"].(map[string]interface{}); ok {
+		if name, _ := currentStatus["name"].(string); strings.EqualFold(name, statusName) {
+			return nil
+		}
+	}
+
+	var transitions struct {
+		Transitions []transition `json:"transitions"`
+	}
+	err = call(config, http.MethodGet, "api/2/issue/"+url.PathEscape(key)+"/transitions", nil, &transitions)
+	if err != nil {
+		return err
+	}
+
+	for _, transition := range transitions.Transitions {
+		if strings.EqualFold(transition.To.Name, statusName) {
+			body := map[string]interface{}{"transition": map[string]string{"id": transition.ID}}
+			return call(config, http.MethodPost, "api/2/issue/"+url.PathEscape(key)+"/transitions", body, nil)
+		}
+	}
+	return fmt.Errorf("issue %s has no transition to status '%s'", key, statusName)
+}
+
+func AddWorklog(config Configuration, key string, hours int, comment string) error {
+	body := map[string]interface{}{"timeSpentSeconds": hours * 3600}
+	if comment != "" {
+		body["comment"] = comment
+	}
+	return call(config, http.MethodPost, "api/2/issue/"+url.PathEscape(key)+"/worklog", body, nil)
+}
+
+func GetStatusName(config Configuration, status string) string {
+	if statusName, ok := config.StatusNames[status]; ok {
+		return statusName
+	}
+	return defaultStatusNames[status]
+}
+
+// Jira Cloud assigns by accountId, Server/Data Center by user name.
+func GetAssignee(config Configuration, workerId string) map[string]string {
+	if accountId, ok := config.AccountIds[workerId]; ok {
+		return map[string]string{"accountId": accountId}
+	}
+	return map[string]string{"name": workerId}
+}
+
+func FormatHours(hours int) string {
+	return strconv.Itoa(hours) + "h"
+}
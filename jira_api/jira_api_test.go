@@ -0,0 +1,159 @@
+package jira_api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Local stand-in for the Jira REST API, recording the mutating calls it receives.
+type fakeJira struct {
+	issues map[string]Issue
+	calls  []string
+}
+
+func newFakeJira() *fakeJira {
+	status := func(name, category string) map[string]interface{} {
+		return map[string]interface{}{"name": name, "statusCategory": map[string]interface{}{"key": category}}
+	}
+	return &fakeJira{issues: map[string]Issue{
+		"PROJ-1": {ID: "10001", Key: "PROJ-1", Fields: map[string]interface{}{"summary": "story", "status": status("To Do", "new")}},
+		"PROJ-2": {ID: "10002", Key: "PROJ-2", Fields: map[string]interface{}{"summary": "task 2", "status": status("To Do", "new"), "parent": map[string]interface{}{"key": "PROJ-1"}}},
+		"PROJ-3": {ID: "10003", Key: "PROJ-3", Fields: map[string]interface{}{"summary": "task 3", "status": status("In Progress", "indeterminate")}},
+	}}
+}
+
+func (fake *fakeJira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	write := func(value interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(value)
+	}
+	record := func() {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		data, _ := json.Marshal(body)
+		fake.calls = append(fake.calls, r.Method+" "+path+" "+string(data))
+	}
+
+	switch {
+	case path == "/rest/agile/1.0/board/7/sprint":
+		if r.URL.Query().Get("startAt") == "0" {
+			write(map[string]interface{}{"isLast": false, "values": []Sprint{{ID: 1, Name: "Sprint 1", State: "closed"}}})
+			return
+		}
+		write(map[string]interface{}{"isLast": true, "values": []Sprint{{ID: 2, Name: "Sprint 2", State: "active"}}})
+	case path == "/rest/agile/1.0/sprint/2/issue":
+		// One issue per page to exercise pagination.
+		if r.URL.Query().Get("startAt") == "0" {
+			write(issuesPage{StartAt: 0, MaxResults: 1, Total: 2, Issues: []Issue{fake.issues["PROJ-2"]}})
+			return
+		}
+		write(issuesPage{StartAt: 1, MaxResults: 1, Total: 2, Issues: []Issue{fake.issues["PROJ-3"]}})
+	case path == "/rest/api/2/issue" && r.Method == http.MethodPost:
+		record()
+		w.WriteHeader(http.StatusCreated)
+		write(Issue{ID: "10004", Key: "PROJ-4"})
+	case strings.HasSuffix(path, "/transitions") && r.Method == http.MethodGet:
+		write(map[string]interface{}{"transitions": []map[string]interface{}{
+			{"id": "11", "to": map[string]string{"name": "To Do"}},
+			{"id": "21", "to": map[string]string{"name": "In Progress"}},
+			{"id": "31", "to": map[string]string{"name": "Done"}},
+		}})
+	case strings.HasSuffix(path, "/transitions") || strings.HasSuffix(path, "/worklog"):
+		record()
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(path, "/rest/api/2/issue/") && r.Method == http.MethodGet:
+		issue, ok := fake.issues[strings.TrimPrefix(path, "/rest/api/2/issue/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		write(issue)
+	case strings.HasPrefix(path, "/rest/api/2/issue/") && r.Method == http.MethodPut:
+		record()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestConfig(server *httptest.Server) Configuration {
+	return Configuration{BaseURL: server.URL, Email: "horey@example.com", APIToken: "token", ProjectKey: "PROJ", BoardID: 7, SprintName: "Sprint 2"}
+}
+
+func TestDownloadSprintIssues(t *testing.T) {
+	t.Run("Sprint with parent outside of it", func(t *testing.T) {
+		server := httptest.NewServer(newFakeJira())
+		defer server.Close()
+
+		dstFilePath := filepath.Join(t.TempDir(), "pre_report.json")
+		err := DownloadSprintIssues(newTestConfig(server), dstFilePath)
+		if err != nil {
+			t.Fatalf("DownloadSprintIssues() error = %v", err)
+		}
+
+		snapshot, err := ReadSnapshotFromFile(dstFilePath)
+		if err != nil {
+			t.Fatalf("ReadSnapshotFromFile() error = %v", err)
+		}
+		if snapshot.Sprint.ID != 2 || len(snapshot.Issues) != 2 || len(snapshot.Parents) != 1 || snapshot.Parents[0].Key != "PROJ-1" {
+			t.Errorf("DownloadSprintIssues() snapshot = %+v", snapshot)
+		}
+	})
+}
+
+func TestSubmitCalls(t *testing.T) {
+	t.Run("Create, update, transition and worklog", func(t *testing.T) {
+		fake := newFakeJira()
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		config := newTestConfig(server)
+
+		key, err := CreateIssue(config, map[string]interface{}{"summary": "new task"})
+		if err != nil || key != "PROJ-4" {
+			t.Fatalf("CreateIssue() = %v, %v", key, err)
+		}
+		err = SetIssueParent(config, "PROJ-3", "PROJ-1")
+		if err != nil {
+			t.Fatalf("SetIssueParent() error = %v", err)
+		}
+		err = TransitionIssue(config, "PROJ-3", "Closed")
+		if err != nil {
+			t.Fatalf("TransitionIssue() error = %v", err)
+		}
+		// Already in the status: no transition is posted.
+		err = TransitionIssue(config, "PROJ-2", "New")
+		if err != nil {
+			t.Fatalf("TransitionIssue() error = %v", err)
+		}
+		err = AddWorklog(config, "PROJ-3", 2, "")
+		if err != nil {
+			t.Fatalf("AddWorklog() error = %v", err)
+		}
+
+		want := []string{
+			`POST /rest/api/2/issue {"fields":{"project":{"key":"PROJ"},"summary":"new task"}}`,
+			`PUT /rest/api/2/issue/PROJ-3 {"fields":{"parent":{"key":"PROJ-1"}}}`,
+			`POST /rest/api/2/issue/PROJ-3/transitions {"transition":{"id":"31"}}`,
+			`POST /rest/api/2/issue/PROJ-3/worklog {"timeSpentSeconds":7200}`,
+		}
+		if !reflect.DeepEqual(fake.calls, want) {
+			t.Errorf("calls = %v, want %v", fake.calls, want)
+		}
+	})
+
+	t.Run("Forbidden transition", func(t *testing.T) {
+		server := httptest.NewServer(newFakeJira())
+		defer server.Close()
+
+		err := TransitionIssue(newTestConfig(server), "PROJ-3", "Blocked")
+		if err == nil {
+			t.Errorf("TransitionIssue() expected error for missing transition")
+		}
+	})
+}